@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexwangfufa/struct-copy/proto/structcopy"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	copierPackage  = protogen.GoImportPath("github.com/alexwangfufa/struct-copy/pkg/copier")
+	reflectPackage = protogen.GoImportPath("reflect")
+)
+
+// mapped is a message and the domain type named by its option
+type mapped struct {
+	message *protogen.Message
+	domain  protogen.GoIdent
+}
+
+// generateFile writes <name>.structcopy.go for the messages of f having the
+// structcopy.domain option, files without any are skipped
+func generateFile(gen *protogen.Plugin, f *protogen.File) error {
+	var messages []mapped
+	if err := collect(&messages, f.Messages); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".structcopy.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-structcopy. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	for _, m := range messages {
+		genMessage(g, m)
+	}
+	return nil
+}
+
+// collect walks messages and their nested messages
+func collect(out *[]mapped, messages []*protogen.Message) error {
+	for _, m := range messages {
+		domain, ok, err := domainType(m)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*out = append(*out, mapped{message: m, domain: domain})
+		}
+		if err := collect(out, m.Messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// domainType reads the structcopy.domain option of m
+func domainType(m *protogen.Message) (protogen.GoIdent, bool, error) {
+	opts, ok := m.Desc.Options().(*descriptorpb.MessageOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, structcopy.E_Domain) {
+		return protogen.GoIdent{}, false, nil
+	}
+
+	name := proto.GetExtension(opts, structcopy.E_Domain).(string)
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 || strings.HasSuffix(name[:i], "/") {
+		return protogen.GoIdent{}, false, fmt.Errorf("%s: structcopy.domain %q should be import/path.Type", m.Desc.FullName(), name)
+	}
+	return protogen.GoIdent{GoName: name[i+1:], GoImportPath: protogen.GoImportPath(name[:i])}, true, nil
+}
+
+// genMessage writes the methods of m and their package level Mappers, which
+// only read the message descriptors on the first copy
+func genMessage(g *protogen.GeneratedFile, m mapped) {
+	msg := m.message.GoIdent.GoName
+	toDomain, fromDomain := "_"+msg+"_toDomain", "_"+msg+"_fromDomain"
+	typeOf := g.QualifiedGoIdent(reflectPackage.Ident("TypeOf"))
+	compile := g.QualifiedGoIdent(copierPackage.Ident("MustCompile"))
+	domain := g.QualifiedGoIdent(m.domain)
+
+	g.P()
+	g.P("var (")
+	g.P(toDomain, " = ", compile, "(", typeOf, "((*", domain, ")(nil)), ", typeOf, "((*", msg, ")(nil)))")
+	g.P(fromDomain, " = ", compile, "(", typeOf, "((*", msg, ")(nil)), ", typeOf, "((*", domain, ")(nil)))")
+	g.P(")")
+	g.P()
+	g.P("// ToDomain converts x into a ", domain, " with the copier rules")
+	g.P("func (x *", msg, ") ToDomain() (*", domain, ", error) {")
+	g.P("if x == nil {")
+	g.P("return nil, nil")
+	g.P("}")
+	g.P("d := new(", domain, ")")
+	g.P("if err := ", toDomain, ".Copy(d, x); err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return d, nil")
+	g.P("}")
+	g.P()
+	g.P("// FromDomain sets x from d with the copier rules")
+	g.P("func (x *", msg, ") FromDomain(d *", domain, ") error {")
+	g.P("if d == nil {")
+	g.P("return nil")
+	g.P("}")
+	g.P("return ", fromDomain, ".Copy(x, d)")
+	g.P("}")
+}
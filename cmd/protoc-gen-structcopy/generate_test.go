@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexwangfufa/struct-copy/proto/structcopy"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// request describes api/material-group/v1/material-group.proto, messages
+// maps message names to their structcopy.domain option
+func request(messages map[string]string) *pluginpb.CodeGeneratorRequest {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("api/material-group/v1/material-group.proto"),
+		Package:    proto.String("api.material_group.v1"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "structcopy/options.proto"},
+		Syntax:     proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("github.com/alexwangfufa/struct-copy/example/api/material-group/v1;v1"),
+		},
+	}
+	for _, name := range []string{"SaveMaterialGroupRequest", "MaterialGroupModel"} {
+		msg := &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("id"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".google.protobuf.StringValue"),
+				JsonName: proto.String("id"),
+			}},
+		}
+		if domain, ok := messages[name]; ok {
+			msg.Options = &descriptorpb.MessageOptions{}
+			proto.SetExtension(msg.Options, structcopy.E_Domain, domain)
+		}
+		file.MessageType = append(file.MessageType, msg)
+	}
+
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+			protodesc.ToFileDescriptorProto(structcopy.File_structcopy_options_proto),
+			file,
+		},
+	}
+}
+
+func generate(t *testing.T, req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			if err := generateFile(gen, f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return gen.Response(), nil
+}
+
+func Test_Generate(t *testing.T) {
+	t.Run("golden", func(t *testing.T) {
+		resp, err := generate(t, request(map[string]string{
+			"SaveMaterialGroupRequest": "github.com/alexwangfufa/struct-copy/example/domain.MaterialGroup",
+		}))
+		if err != nil || resp.Error != nil {
+			t.Fatalf("generate error = %v %v", err, resp.GetError())
+		}
+		if len(resp.File) != 1 || resp.File[0].GetName() != "api/material-group/v1/material-group.structcopy.go" {
+			t.Fatalf("unexpected files: %v", resp.File)
+		}
+
+		golden := "testdata/material-group.structcopy.go.golden"
+		if *update {
+			if err := os.WriteFile(golden, []byte(resp.File[0].GetContent()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.File[0].GetContent(); got != string(want) {
+			t.Fatalf("output differs from %s, rerun with -update\n%s", golden, got)
+		}
+	})
+
+	t.Run("no option", func(t *testing.T) {
+		resp, err := generate(t, request(nil))
+		if err != nil || len(resp.File) != 0 {
+			t.Fatalf("expected no output, got %v %v", resp.GetFile(), err)
+		}
+	})
+
+	for _, domain := range []string{"MaterialGroup", "github.com/org/app/domain.", "github.com/org/app/.MaterialGroup"} {
+		t.Run("invalid "+domain, func(t *testing.T) {
+			_, err := generate(t, request(map[string]string{"MaterialGroupModel": domain}))
+			if err == nil || !strings.Contains(err.Error(), "should be import/path.Type") {
+				t.Fatalf("expected an invalid option error, got %v", err)
+			}
+		})
+	}
+}
+
+// roundTrip is the test run inside the package built from the plugin output
+const roundTrip = `package v1
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	d, err := (&SaveMaterialGroupRequest{Name: "name", Order: wrapperspb.Int64(2)}).ToDomain()
+	if err != nil || d.Name != "name" || d.Order != 2 {
+		t.Fatalf("ToDomain() = %+v, %v", d, err)
+	}
+	x := &SaveMaterialGroupRequest{}
+	if err := x.FromDomain(d); err != nil || x.Name != "name" || x.Order.GetValue() != 2 {
+		t.Fatalf("FromDomain() = %v, %v", x, err)
+	}
+}
+`
+
+// the plugin output builds and runs next to the messages it belongs to, its
+// package level Mappers are created before the init that registers their descriptors
+func Test_GeneratedPackage(t *testing.T) {
+	resp, err := generate(t, request(map[string]string{
+		"SaveMaterialGroupRequest": "github.com/alexwangfufa/struct-copy/example/domain.MaterialGroup",
+	}))
+	if err != nil || resp.Error != nil {
+		t.Fatalf("generate error = %v %v", err, resp.GetError())
+	}
+
+	// the package lives in the module so that its imports resolve
+	dir, err := os.MkdirTemp("testdata", "v1-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	messages, err := os.ReadFile("../../example/api/material-group/v1/material-group.pb.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"material-group.pb.go":         string(messages),
+		"material-group.structcopy.go": resp.File[0].GetContent(),
+		"roundtrip_test.go":            roundTrip,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command("go", "test", "./"+dir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go test of the generated package: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,37 @@
+// Command protoc-gen-structcopy is a protoc plugin generating ToDomain and
+// FromDomain methods for messages that name their Go domain type with the
+// structcopy.domain option of proto/structcopy/options.proto:
+//
+//	import "structcopy/options.proto";
+//
+//	message SaveMaterialGroupRequest {
+//	  option (structcopy.domain) = "github.com/org/app/domain.MaterialGroup";
+//	  ...
+//	}
+//
+// The methods copy with a copier.Mapper compiled once per message, so they
+// follow the copier rules for wrapperspb, timestamppb and ObjectID fields.
+// The output goes next to the .pb.go files, run it together with protoc-gen-go:
+//
+//	protoc -I proto -I . --go_out=. --structcopy_out=. api/material-group/v1/material-group.proto
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	protogen.Options{}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,832 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexwangfufa/struct-copy/pkg/copier"
+)
+
+const (
+	copierPath      = "github.com/alexwangfufa/struct-copy/pkg/copier"
+	wrapperspbPath  = "google.golang.org/protobuf/types/known/wrapperspb"
+	timestamppbPath = "google.golang.org/protobuf/types/known/timestamppb"
+	durationpbPath  = "google.golang.org/protobuf/types/known/durationpb"
+	primitivePath   = "go.mongodb.org/mongo-driver/bson/primitive"
+
+	objectID    = primitivePath + ".ObjectID"
+	stringValue = "*" + wrapperspbPath + ".StringValue"
+	timestamp   = "*" + timestamppbPath + ".Timestamp"
+	timeTime    = "time.Time"
+	timePtr     = "*time.Time"
+	dateTime    = primitivePath + ".DateTime"
+	duration    = "time.Duration"
+	pbDuration  = "*" + durationpbPath + ".Duration"
+)
+
+// wrapper is a wrapperspb message and the type of its Value
+type wrapper struct {
+	value types.Type
+	ctor  string
+}
+
+// wrappers is keyed by the wrapper pointer type, these are the pairs copier registers
+var wrappers = map[string]wrapper{
+	"*" + wrapperspbPath + ".StringValue": {types.Typ[types.String], "String"},
+	"*" + wrapperspbPath + ".Int64Value":  {types.Typ[types.Int64], "Int64"},
+	"*" + wrapperspbPath + ".Int32Value":  {types.Typ[types.Int32], "Int32"},
+	"*" + wrapperspbPath + ".UInt64Value": {types.Typ[types.Uint64], "UInt64"},
+	"*" + wrapperspbPath + ".UInt32Value": {types.Typ[types.Uint32], "UInt32"},
+	"*" + wrapperspbPath + ".DoubleValue": {types.Typ[types.Float64], "Double"},
+	"*" + wrapperspbPath + ".FloatValue":  {types.Typ[types.Float32], "Float"},
+	"*" + wrapperspbPath + ".BoolValue":   {types.Typ[types.Bool], "Bool"},
+	"*" + wrapperspbPath + ".BytesValue":  {types.NewSlice(types.Typ[types.Byte]), "Bytes"},
+}
+
+// Generate returns the source of the copy functions for pairs, type names are
+// resolved through the imports of file and the output belongs to its package
+func Generate(file string, pairs [][2]string, ignore []string) ([]byte, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	pkgPath, err := importPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{
+		dir:      dir,
+		pkgPath:  pkgPath,
+		file:     f,
+		importer: importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+		packages: make(map[string]*types.Package),
+	}
+	g := &generator{
+		pkgPath: pkgPath,
+		imports: make(map[string]string),
+		taken:   make(map[string]bool),
+		funcs:   make(map[[2]*types.Named]string),
+		ignore:  make(map[string]bool),
+	}
+	for _, name := range ignore {
+		g.ignore[strings.TrimSpace(name)] = true
+	}
+
+	var todo []copyFunc
+	for _, pair := range pairs {
+		a, err := l.lookup(pair[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := l.lookup(pair[1])
+		if err != nil {
+			return nil, err
+		}
+		todo = append(todo, g.declare(b, a), g.declare(a, b))
+	}
+
+	for _, fn := range todo {
+		if err := g.writeFunc(fn); err != nil {
+			return nil, err
+		}
+	}
+	return g.source(f.Name.Name)
+}
+
+// importPath asks the go command for the import path of the package in dir
+func importPath(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list in %s: %v", dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// loader resolves qualified type names the way the source file sees them
+type loader struct {
+	dir      string
+	pkgPath  string
+	file     *ast.File
+	importer types.ImporterFrom
+	packages map[string]*types.Package
+}
+
+func (l *loader) load(path string) (*types.Package, error) {
+	if pkg, ok := l.packages[path]; ok {
+		return pkg, nil
+	}
+	pkg, err := l.importer.ImportFrom(path, l.dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	l.packages[path] = pkg
+	return pkg, nil
+}
+
+// packageOf finds the package a qualifier stands for: an import path, an
+// import alias or the name of an imported package, or the file's own package
+func (l *loader) packageOf(qualifier string) (*types.Package, error) {
+	if qualifier == "" || strings.Contains(qualifier, "/") {
+		path := qualifier
+		if path == "" {
+			path = l.pkgPath
+		}
+		return l.load(path)
+	}
+
+	for _, spec := range l.file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Name != nil && spec.Name.Name != "_" && spec.Name.Name != "." {
+			if spec.Name.Name == qualifier {
+				return l.load(path)
+			}
+			continue
+		}
+		pkg, err := l.load(path)
+		if err != nil {
+			return nil, err
+		}
+		if pkg.Name() == qualifier {
+			return pkg, nil
+		}
+	}
+	return nil, fmt.Errorf("no import of %s in %s", qualifier, l.file.Name.Name)
+}
+
+// lookup resolves a [qualifier.]Name struct type
+func (l *loader) lookup(name string) (*types.Named, error) {
+	qualifier, typeName := "", name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, typeName = name[:i], name[i+1:]
+	}
+
+	pkg, err := l.packageOf(qualifier)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type of %s", typeName, pkg.Path())
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a named type", name)
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("%s is not a struct type", name)
+	}
+	return named, nil
+}
+
+// copyFunc is one generated function, copying src into dst
+type copyFunc struct {
+	name string
+	dst  *types.Named
+	src  *types.Named
+}
+
+type generator struct {
+	pkgPath string
+	// imports maps the import paths used by the output to their names, taken
+	// holds the names in use
+	imports map[string]string
+	taken   map[string]bool
+	// funcs names the generated function of every [dst, src] pair
+	funcs  map[[2]*types.Named]string
+	ignore map[string]bool
+	body   bytes.Buffer
+	// zero is the zero= tag of the field being written
+	zero string
+}
+
+// declare names the function copying src into dst
+func (g *generator) declare(dst, src *types.Named) copyFunc {
+	name := src.Obj().Name() + "To" + dst.Obj().Name()
+	if src.Obj().Name() == dst.Obj().Name() {
+		name = exported(src.Obj().Pkg().Name()) + src.Obj().Name() + "To" + exported(dst.Obj().Pkg().Name()) + dst.Obj().Name()
+	}
+	g.funcs[[2]*types.Named{dst, src}] = name
+	return copyFunc{name: name, dst: dst, src: src}
+}
+
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// use adds an import and returns the name it goes by in the output
+func (g *generator) use(path, name string) string {
+	if path == g.pkgPath {
+		return ""
+	}
+	if used, ok := g.imports[path]; ok {
+		return used
+	}
+	used := name
+	for i := 2; g.taken[used]; i++ {
+		used = name + strconv.Itoa(i)
+	}
+	g.imports[path] = used
+	g.taken[used] = true
+	return used
+}
+
+func (g *generator) qualifier(pkg *types.Package) string {
+	return g.use(pkg.Path(), pkg.Name())
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) writeFunc(fn copyFunc) error {
+	dstStruct := fn.dst.Underlying().(*types.Struct)
+	srcStruct := fn.src.Underlying().(*types.Struct)
+	dstFields, srcFields := structFields(dstStruct), structFields(srcStruct)
+
+	var body bytes.Buffer
+	for i, m := range copier.MatchFields(dstFields, srcFields) {
+		field := dstFields[i]
+		if m.Ignored || g.ignore[field.Name] || g.ignore[fn.dst.Obj().Name()+"."+field.Name] {
+			continue
+		}
+		if m.Src < 0 {
+			return fmt.Errorf("%s.%s has no source field in %s", fn.dst.Obj().Name(), field.Name, fn.src.Obj().Name())
+		}
+		srcField := srcFields[m.Src]
+		g.zero = m.Zero
+		err := g.convert(&body, "dst."+field.Name, "src."+srcField.Name,
+			dstStruct.Field(i).Type(), srcStruct.Field(m.Src).Type(), path{format: field.Name}, 0)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", fn.dst.Obj().Name(), field.Name, err)
+		}
+	}
+
+	fmt.Fprintf(&g.body, "\n// %s copies src into dst with the rules of copier.Copy\n", fn.name)
+	fmt.Fprintf(&g.body, "func %s(dst *%s, src *%s) error {\n", fn.name, g.typeString(fn.dst), g.typeString(fn.src))
+	fmt.Fprintf(&g.body, "if src == nil {\nreturn nil\n}\n%sreturn nil\n}\n", body.String())
+	return nil
+}
+
+// structFields describes the fields of s for copier.MatchFields
+func structFields(s *types.Struct) []reflect.StructField {
+	fields := make([]reflect.StructField, s.NumFields())
+	for i := range fields {
+		v := s.Field(i)
+		fields[i] = reflect.StructField{Name: v.Name(), Tag: reflect.StructTag(s.Tag(i)), Index: []int{i}}
+		if !v.Exported() {
+			fields[i].PkgPath = v.Pkg().Path()
+		}
+	}
+	return fields
+}
+
+// path is the Go expression of a field path, a format and its loop variables
+type path struct {
+	format string
+	args   []string
+}
+
+func (p path) index(v string) path {
+	return path{format: p.format + "[%d]", args: append(append([]string(nil), p.args...), v)}
+}
+
+func (g *generator) pathExpr(p path) string {
+	if len(p.args) == 0 {
+		return strconv.Quote(p.format)
+	}
+	return fmt.Sprintf("%s.Sprintf(%s, %s)", g.use("fmt", "fmt"), strconv.Quote(p.format), strings.Join(p.args, ", "))
+}
+
+// convert writes the statements copying the src expression into the dst
+// expression. Nil pointers and slices are skipped, as copier.Copy does.
+func (g *generator) convert(w *bytes.Buffer, dst, src string, dt, st types.Type, p path, depth int) error {
+	switch st.Underlying().(type) {
+	case *types.Pointer, *types.Slice:
+		var inner bytes.Buffer
+		if err := g.convertValue(&inner, dst, src, dt, st, p, depth); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "if %s != nil {\n%s}\n", src, inner.String())
+		return nil
+	}
+	return g.convertValue(w, dst, src, dt, st, p, depth)
+}
+
+// convertValue picks the conversion in the order of copier's rules: the built
+// in converters, assignability, basic conversions, nested structs and slices
+func (g *generator) convertValue(w *bytes.Buffer, dst, src string, dt, st types.Type, p path, depth int) error {
+	sk, dk := types.TypeString(st, nil), types.TypeString(dt, nil)
+
+	if wr, ok := wrappers[sk]; ok {
+		switch {
+		case types.Identical(dt, wr.value):
+			fmt.Fprintf(w, "%s = %s\n", dst, cloneBytes(wr, src+".GetValue()"))
+			return nil
+		case types.Identical(dt, types.NewPointer(wr.value)):
+			fmt.Fprintf(w, "v := %s\n%s = &v\n", cloneBytes(wr, src+".GetValue()"), dst)
+			return nil
+		case namedBasic(dt) != nil && !ownRules(dk) && types.Identical(dt.Underlying(), wr.value):
+			g.assign(w, dst, g.typeString(dt)+"("+src+".GetValue())", dt, src, p)
+			return nil
+		}
+	}
+	if wr, ok := wrappers[dk]; ok {
+		ctor := g.use(wrapperspbPath, "wrapperspb") + "." + wr.ctor
+		switch {
+		case types.Identical(st, wr.value) && g.zeroToNil(false):
+			fmt.Fprintf(w, "if %s {\n%s = nil\n} else {\n%s = %s(%s)\n}\n", isZero(wr.value, src), dst, dst, ctor, cloneBytes(wr, src))
+			return nil
+		case types.Identical(st, wr.value):
+			fmt.Fprintf(w, "%s = %s(%s)\n", dst, ctor, cloneBytes(wr, src))
+			return nil
+		case types.Identical(st, types.NewPointer(wr.value)):
+			fmt.Fprintf(w, "%s = %s(%s)\n", dst, ctor, cloneBytes(wr, "*"+src))
+			return nil
+		case namedBasic(st) != nil && !ownRules(sk) && types.Identical(st.Underlying(), wr.value):
+			value := g.typeString(wr.value) + "(" + src + ")"
+			if g.zeroToNil(false) {
+				fmt.Fprintf(w, "if %s {\n%s = nil\n} else {\n%s = %s(%s)\n}\n", isZero(wr.value, src), dst, dst, ctor, value)
+				return nil
+			}
+			fmt.Fprintf(w, "%s = %s(%s)\n", dst, ctor, value)
+			return nil
+		}
+	}
+
+	if isObjectID(sk) {
+		switch {
+		case isString(dt):
+			fmt.Fprintf(w, "%s = %s.Hex()\n", dst, src)
+			return nil
+		case dk == stringValue:
+			fmt.Fprintf(w, "%s = %s.String(%s.Hex())\n", dst, g.use(wrapperspbPath, "wrapperspb"), src)
+			return nil
+		case isBytes(dt):
+			fmt.Fprintf(w, "%s = append([]byte(nil), %s[:]...)\n", dst, src)
+			return nil
+		case sk == objectID && dk == "*"+objectID:
+			fmt.Fprintf(w, "if %s.IsZero() {\n%s = nil\n} else {\nid := %s\n%s = &id\n}\n", src, dst, src, dst)
+			return nil
+		case sk == "*"+objectID && dk == objectID:
+			fmt.Fprintf(w, "%s = *%s\n", dst, src)
+			return nil
+		}
+	}
+	if isObjectID(dk) {
+		switch {
+		case isString(st), sk == stringValue:
+			hex := src
+			if sk == stringValue {
+				hex = src + ".GetValue()"
+			}
+			fmt.Fprintf(w, "if hex := %s; hex != \"\" {\n", hex)
+			fmt.Fprintf(w, "id, err := %s.ObjectIDFromHex(hex)\n", g.use(primitivePath, "primitive"))
+			fmt.Fprintf(w, "if err != nil {\nreturn %s\n}\n", g.copyError(p, dst, src, "err"))
+			g.setObjectID(w, dst, dk)
+			fmt.Fprintf(w, "}\n")
+			return nil
+		case isBytes(st):
+			fmt.Fprintf(w, "if b := %s; len(b) != 0 {\n", src)
+			fmt.Fprintf(w, "if len(b) != 12 {\nreturn %s\n}\n", g.copyError(p, dst, src, g.use("fmt", "fmt")+".Errorf(\"object id should be 12 bytes, got %d\", len(b))"))
+			fmt.Fprintf(w, "id := *(*%s.ObjectID)(b)\n", g.use(primitivePath, "primitive"))
+			g.setObjectID(w, dst, dk)
+			fmt.Fprintf(w, "}\n")
+			return nil
+		}
+	}
+
+	if isTime(sk) && isTime(dk) {
+		g.convertTime(w, dst, src, sk, dk, p)
+		return nil
+	}
+
+	if sk == duration && dk == pbDuration {
+		fmt.Fprintf(w, "%s = %s.New(%s)\n", dst, g.use(durationpbPath, "durationpb"), src)
+		return nil
+	}
+	if sk == pbDuration && dk == duration {
+		// AsDuration saturates, converting back tells a duration time.Duration can't hold
+		fmt.Fprintf(w, "if err := %s.CheckValid(); err != nil {\nreturn %s\n}\n", src, g.copyError(p, dst, src, g.rangeError()))
+		fmt.Fprintf(w, "if back := %s.New(%s.AsDuration()); back.Seconds != %s.Seconds || back.Nanos != %s.Nanos {\n", g.use(durationpbPath, "durationpb"), src, src, src)
+		fmt.Fprintf(w, "return %s\n}\n", g.copyError(p, dst, src, g.use(copierPath, "copier")+".ErrTimeRange"))
+		fmt.Fprintf(w, "%s = %s.AsDuration()\n", dst, src)
+		return nil
+	}
+
+	if types.AssignableTo(st, dt) {
+		g.assign(w, dst, src, dt, src, p)
+		return nil
+	}
+	if sameFamily(st, dt) {
+		if cond := g.overflows(src, st, dt); cond != "" {
+			fmt.Fprintf(w, "if %s {\nreturn %s\n}\n", cond, g.copyError(p, dst, src, g.use(copierPath, "copier")+".ErrOverflow"))
+		}
+		g.assign(w, dst, g.typeString(dt)+"("+src+")", dt, src, p)
+		return nil
+	}
+
+	// scalar pointers convert what they point to, a pointer source keeps its
+	// presence and its zero value is never dropped
+	if se := scalarElem(st); se != nil {
+		zero := g.zero
+		g.zero = "keep"
+		err := g.convertValue(w, dst, "*"+src, dt, se, p, depth)
+		g.zero = zero
+		return err
+	}
+	if de := scalarElem(dt); de != nil {
+		var inner bytes.Buffer
+		if err := g.convertValue(&inner, "v", src, de, st, p, depth); err != nil {
+			return err
+		}
+		set := fmt.Sprintf("var v %s\n%s%s = &v\n", g.typeString(de), inner.String(), dst)
+		if line := inner.String(); strings.HasPrefix(line, "v = ") && strings.Count(line, "\n") == 1 {
+			set = fmt.Sprintf("v := %s%s = &v\n", strings.TrimPrefix(line, "v = "), dst)
+		}
+		if _, ok := st.Underlying().(*types.Basic); ok && g.zeroToNil(false) {
+			fmt.Fprintf(w, "if %s {\n%s = nil\n} else {\n%s}\n", isZero(st, src), dst, set)
+			return nil
+		}
+		fmt.Fprintf(w, "{\n%s}\n", set)
+		return nil
+	}
+
+	if sn, dn := namedStruct(st), namedStruct(dt); sn != nil && dn != nil {
+		return g.convertNested(w, dst, src, dt, st, dn, sn, p)
+	}
+
+	if se, de := elem(st), elem(dt); se != nil && de != nil {
+		if _, ok := dt.Underlying().(*types.Slice); ok {
+			return g.convertSlice(w, dst, src, dt, se, de, p, depth)
+		}
+	}
+
+	return fmt.Errorf("no rule copies %s into %s", g.typeString(st), g.typeString(dt))
+}
+
+// convertNested calls the generated function of a struct pair, a nil dst pointer is allocated
+func (g *generator) convertNested(w *bytes.Buffer, dst, src string, dt, st types.Type, dn, sn *types.Named, p path) error {
+	name, ok := g.funcs[[2]*types.Named{dn, sn}]
+	if !ok {
+		return fmt.Errorf("no function copies %s into %s, add a -pair for them", g.typeString(sn), g.typeString(dn))
+	}
+
+	srcArg, dstArg := src, dst
+	if _, ok := st.(*types.Pointer); !ok {
+		srcArg = "&" + src
+	}
+	if _, ok := dt.(*types.Pointer); ok {
+		fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", dst, dst, g.typeString(dn))
+	} else {
+		dstArg = "&" + dst
+	}
+
+	fmt.Fprintf(w, "if err := %s(%s, %s); err != nil {\n", name, dstArg, srcArg)
+	fmt.Fprintf(w, "if ce, ok := err.(*%s.CopyError); ok {\nce.Path = %s + \".\" + ce.Path\n}\n", g.use(copierPath, "copier"), g.pathExpr(p))
+	fmt.Fprintf(w, "return err\n}\n")
+	return nil
+}
+
+// convertSlice allocates the dst slice and converts every element
+func (g *generator) convertSlice(w *bytes.Buffer, dst, src string, dt, se, de types.Type, p path, depth int) error {
+	i := loopVar(depth)
+	var inner bytes.Buffer
+	if err := g.convert(&inner, dst+"["+i+"]", src+"["+i+"]", de, se, p.index(i), depth+1); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s = make(%s, len(%s))\n", dst, g.typeString(dt), src)
+	fmt.Fprintf(w, "for %s := range %s {\n%s}\n", i, src, inner.String())
+	return nil
+}
+
+// convertTime converts between time.Time, *time.Time, *timestamppb.Timestamp
+// and primitive.DateTime, zero times become nil pointers as with the default
+// ZeroTimes policy unless the field is tagged zero=keep, and timestamps out of
+// range fail with copier.ErrTimeRange
+func (g *generator) convertTime(w *bytes.Buffer, dst, src, sk, dk string, p path) {
+	var t string
+	switch sk {
+	case timeTime:
+		t = src
+	case timePtr:
+		t = "*" + src
+	case timestamp:
+		fmt.Fprintf(w, "if err := %s.CheckValid(); err != nil {\nreturn %s\n}\n", src, g.copyError(p, dst, src, g.rangeError()))
+		t = src + ".AsTime()"
+	case dateTime:
+		t = src + ".Time().UTC()"
+	}
+
+	switch dk {
+	case timeTime:
+		fmt.Fprintf(w, "%s = %s\n", dst, t)
+	case timePtr:
+		if !g.zeroToNil(true) {
+			fmt.Fprintf(w, "{\nt := %s\n%s = &t\n}\n", t, dst)
+			break
+		}
+		fmt.Fprintf(w, "if t := %s; t.IsZero() {\n%s = nil\n} else {\n%s = &t\n}\n", t, dst, dst)
+	case timestamp:
+		if g.zeroToNil(true) {
+			fmt.Fprintf(w, "if t := %s; t.IsZero() {\n%s = nil\n} else {\n", t, dst)
+		} else {
+			fmt.Fprintf(w, "{\nt := %s\n", t)
+		}
+		fmt.Fprintf(w, "ts := %s.New(t)\n", g.use(timestamppbPath, "timestamppb"))
+		fmt.Fprintf(w, "if err := ts.CheckValid(); err != nil {\nreturn %s\n}\n", g.copyError(p, dst, src, g.rangeError()))
+		fmt.Fprintf(w, "%s = ts\n}\n", dst)
+	case dateTime:
+		fmt.Fprintf(w, "%s = %s.NewDateTimeFromTime(%s)\n", dst, g.use(primitivePath, "primitive"), t)
+	}
+}
+
+func (g *generator) rangeError() string {
+	return fmt.Sprintf("%s.Errorf(\"%%w: %%v\", %s.ErrTimeRange, err)", g.use("fmt", "fmt"), g.use(copierPath, "copier"))
+}
+
+// assign writes expr into dst. A named basic dst is checked with
+// copier.CheckEnum first, it may be an enum registered with copier.RegisterEnum.
+func (g *generator) assign(w *bytes.Buffer, dst, expr string, dt types.Type, src string, p path) {
+	if namedBasic(dt) != nil && !isTime(types.TypeString(dt, nil)) && types.TypeString(dt, nil) != duration {
+		fmt.Fprintf(w, "if err := %s.CheckEnum(%s); err != nil {\nreturn %s\n}\n", g.use(copierPath, "copier"), expr, g.copyError(p, dst, src, "err"))
+	}
+	fmt.Fprintf(w, "%s = %s\n", dst, expr)
+}
+
+// namedBasic returns the underlying type of a named basic type such as
+// domain.MaterialGroupType, nil for other types
+func namedBasic(t types.Type) *types.Basic {
+	if _, ok := t.(*types.Named); !ok {
+		return nil
+	}
+	b, _ := t.Underlying().(*types.Basic)
+	return b
+}
+
+// ownRules reports whether the named basic type t has conversions of its
+// own, which the wrappers of its base type must not take over, as in copier
+func ownRules(t string) bool {
+	return t == duration || t == dateTime
+}
+
+// scalarElem returns what t points to when it is a pointer to a bool, a
+// number or a string, nil otherwise
+func scalarElem(t types.Type) types.Type {
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	if b, ok := ptr.Elem().Underlying().(*types.Basic); ok && b.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0 && b.Info()&types.IsComplex == 0 {
+		return ptr.Elem()
+	}
+	return nil
+}
+
+// copyError is the expression of a *copier.CopyError for the field at p
+func (g *generator) copyError(p path, dst, src, err string) string {
+	return fmt.Sprintf("&%s.CopyError{Path: %s, SrcType: %s.TypeOf(%s), DstType: %s.TypeOf(%s), Value: %s, Err: %s}",
+		g.use(copierPath, "copier"), g.pathExpr(p), g.use("reflect", "reflect"), src, g.use("reflect", "reflect"), dst, src, err)
+}
+
+func loopVar(depth int) string {
+	if depth < 3 {
+		return string(rune('i' + depth))
+	}
+	return "i" + strconv.Itoa(depth)
+}
+
+// zeroToNil reports whether a zero value of the current field becomes nil,
+// def is the default of the copier option for the kind of value
+func (g *generator) zeroToNil(def bool) bool {
+	switch g.zero {
+	case "nil":
+		return true
+	case "keep":
+		return false
+	}
+	return def
+}
+
+// cloneBytes copies a []byte expression, as copier does, other expressions are
+// returned as they are
+func cloneBytes(wr wrapper, expr string) string {
+	if _, ok := wr.value.(*types.Slice); ok {
+		return "append([]byte(nil), " + expr + "...)"
+	}
+	return expr
+}
+
+// isZero is the condition for a zero value of t, a basic type or []byte
+func isZero(t types.Type, expr string) string {
+	b, ok := t.Underlying().(*types.Basic)
+	switch {
+	case !ok:
+		return "len(" + expr + ") == 0"
+	case b.Info()&types.IsString != 0:
+		return expr + ` == ""`
+	case b.Info()&types.IsBoolean != 0:
+		return "!" + expr
+	}
+	return expr + " == 0"
+}
+
+func isTime(key string) bool {
+	return key == timeTime || key == timePtr || key == timestamp || key == dateTime
+}
+
+// setObjectID stores the variable id into dst, a pointer is set to nil for the zero id
+func (g *generator) setObjectID(w *bytes.Buffer, dst, dk string) {
+	if dk == objectID {
+		fmt.Fprintf(w, "%s = id\n", dst)
+		return
+	}
+	fmt.Fprintf(w, "if id.IsZero() {\n%s = nil\n} else {\n%s = &id\n}\n", dst, dst)
+}
+
+func isBytes(t types.Type) bool {
+	return types.Identical(t, types.NewSlice(types.Typ[types.Byte]))
+}
+
+func isObjectID(key string) bool {
+	return key == objectID || key == "*"+objectID
+}
+
+func isString(t types.Type) bool {
+	return types.Identical(t, types.Typ[types.String])
+}
+
+// sameFamily mirrors copier's convertible: numbers, strings and bools convert
+// within their own family only, numbers are range checked, see overflows
+func sameFamily(st, dt types.Type) bool {
+	sb, ok := st.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	db, ok := dt.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	for _, family := range []types.BasicInfo{types.IsInteger | types.IsFloat, types.IsString, types.IsBoolean} {
+		if sb.Info()&family != 0 && db.Info()&family != 0 {
+			return sb.Info()&types.IsComplex == 0 && db.Info()&types.IsComplex == 0
+		}
+	}
+	return false
+}
+
+// overflows is the condition under which the number src of type st doesn't
+// fit dt, empty when dt holds every value of st. As with copier's
+// convertNumber a float into an integer must be whole, and a float may lose
+// precision.
+func (g *generator) overflows(src string, st, dt types.Type) string {
+	sb, _ := st.Underlying().(*types.Basic)
+	db, _ := dt.Underlying().(*types.Basic)
+	if sb == nil || db == nil || sb.Info()&types.IsNumeric == 0 {
+		return ""
+	}
+	conv := g.typeString(dt) + "(" + src + ")"
+	back := g.typeString(st) + "(" + conv + ") != " + src
+	si, di := sb.Info(), db.Info()
+	switch {
+	case di&types.IsFloat != 0:
+		if si&types.IsFloat == 0 || basicBits(sb, true) <= basicBits(db, false) {
+			return ""
+		}
+		m := g.use("math", "math")
+		return fmt.Sprintf("%s.Abs(float64(%s)) > %s.MaxFloat32", m, src, m)
+	case si&types.IsFloat != 0:
+		// the result of converting a float out of range depends on the platform
+		m := g.use("math", "math")
+		name := strings.ToUpper(db.Name()[:1]) + db.Name()[1:]
+		if db.Kind() == types.Uintptr {
+			name = "Uint"
+		}
+		min, max := m+".Min"+name, "-"+m+".Min"+name
+		if di&types.IsUnsigned != 0 {
+			min, max = "0", m+".Max"+name+" + 1"
+		}
+		return fmt.Sprintf("float64(%s) != %s.Trunc(float64(%s)) || %s < %s || %s >= %s", src, m, src, src, min, src, max)
+	case si&types.IsUnsigned == di&types.IsUnsigned:
+		if basicBits(db, false) >= basicBits(sb, true) {
+			return ""
+		}
+		return back
+	case si&types.IsUnsigned != 0:
+		if basicBits(db, false) > basicBits(sb, true) {
+			return ""
+		}
+		return conv + " < 0 || " + back
+	}
+	return src + " < 0 || " + back
+}
+
+// basicBits is the size of a number type, int, uint and uintptr count as
+// 64 bits when max is set and 32 otherwise, so that checks hold on every platform
+func basicBits(b *types.Basic, max bool) int {
+	switch b.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64:
+		return 64
+	}
+	if max {
+		return 64
+	}
+	return 32
+}
+
+// namedStruct returns the named struct t is or points to
+func namedStruct(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return named
+}
+
+// elem returns the element type of a slice or array
+func elem(t types.Type) types.Type {
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return u.Elem()
+	case *types.Array:
+		return u.Elem()
+	}
+	return nil
+}
+
+// source assembles and formats the output file
+func (g *generator) source(pkgName string) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by structcopy-gen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkgName)
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// standard library first, as goimports groups them
+	sort.Slice(paths, func(i, j int) bool {
+		if si, sj := isStd(paths[i]), isStd(paths[j]); si != sj {
+			return si
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && isStd(path) != isStd(paths[i-1]) {
+			out.WriteString("\n")
+		}
+		name := g.imports[path]
+		if name == filepath.Base(path) {
+			fmt.Fprintf(&out, "%q\n", path)
+		} else {
+			fmt.Fprintf(&out, "%s %q\n", name, path)
+		}
+	}
+	fmt.Fprintf(&out, ")\n%s", g.body.String())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting output: %v\n%s", err, out.String())
+	}
+	return src, nil
+}
+
+func isStd(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const example = "../../example/mapper/mapper.go"
+
+// exampleArgs reads the flags of the go:generate directive in the example
+func exampleArgs(t *testing.T) (pairList, []string) {
+	src, err := os.ReadFile(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(src), "\n") {
+		if !strings.HasPrefix(line, "//go:generate ") {
+			continue
+		}
+		var pairs pairList
+		fs := flag.NewFlagSet("structcopy-gen", flag.ContinueOnError)
+		fs.Var(&pairs, "pair", "")
+		ignore := fs.String("ignore", "", "")
+		if err := fs.Parse(strings.Fields(line)[4:]); err != nil {
+			t.Fatal(err)
+		}
+		return pairs, strings.Split(*ignore, ",")
+	}
+	t.Fatal("no go:generate directive in", example)
+	return nil, nil
+}
+
+func Test_Generate(t *testing.T) {
+	pairs, ignore := exampleArgs(t)
+
+	t.Run("golden", func(t *testing.T) {
+		got, err := Generate(example, pairs, ignore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(strings.TrimSuffix(example, ".go") + "_structcopy.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("generated code differs from the committed file, run go generate in example/mapper\n%s", got)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		pairs  pairList
+		ignore []string
+		err    string
+	}{
+		{
+			name:   "unsupported field",
+			pairs:  pairList{{"go/ast.TypeSpec", "go/ast.FuncDecl"}},
+			ignore: []string{"TypeParams", "Assign", "Comment", "Recv", "Body"},
+			err:    "FuncDecl.Type: no rule copies ast.Expr into *ast.FuncType",
+		},
+		{
+			name:   "missing source",
+			pairs:  pairList{{"domain.MaterialGroup", "v1.MaterialGroupModel"}},
+			ignore: []string{"Type", "Scope"},
+			err:    "MaterialGroup.Ut64 has no source field in MaterialGroupModel",
+		},
+		{
+			name:  "missing pair",
+			pairs: pairList{{"MaterialGroupPage", "v1.MaterialGroupModelList"}},
+			err:   "MaterialGroupModelList.Data: no function copies MaterialGroupSummary into v1.MaterialGroupModel",
+		},
+		{
+			name:  "unknown import",
+			pairs: pairList{{"model.MaterialGroup", "v1.MaterialGroupModel"}},
+			err:   "no import of model",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(example, tt.pairs, tt.ignore)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Generate() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// keepTimes has two fields that keep their zero time
+const keepTimes = `package times
+
+import "time"
+
+type Event struct {
+	Start time.Time
+	End   time.Time
+}
+
+type EventModel struct {
+	Start *time.Time ` + "`copier:\"zero=keep\"`" + `
+	End   *time.Time ` + "`copier:\"zero=keep\"`" + `
+}
+`
+
+// generatePackage writes source and the functions generated for pairs into a
+// temporary package of the module, which is removed after the test. A _
+// directory is left out of ./... and is still built when named.
+func generatePackage(t *testing.T, name, source string, pairs pairList) (dir string, got []byte) {
+	dir, err := os.MkdirTemp(".", "_"+name+"-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	file := filepath.Join(dir, name+".go")
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = Generate(file, pairs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+"_structcopy.go"), got, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, got
+}
+
+// the generated functions pass go vet, each field copy has its own scope
+func Test_GenerateVet(t *testing.T) {
+	dir, got := generatePackage(t, "times", keepTimes, pairList{{"Event", "EventModel"}})
+	if out, err := exec.Command("go", "vet", "./"+dir).CombinedOutput(); err != nil {
+		t.Fatalf("go vet: %v\n%s\n%s", err, out, got)
+	}
+}
+
+// narrowing holds number fields converted into smaller types, and the test
+// run against the functions generated for them
+const (
+	narrowing = `package numbers
+
+type Wide struct {
+	N int64
+	U uint64
+	I int
+	F float64
+	G float64
+}
+
+type Narrow struct {
+	N int8
+	U int32
+	I uint
+	F float32
+	G int64
+}
+`
+	narrowingTest = `package numbers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexwangfufa/struct-copy/pkg/copier"
+)
+
+func TestNarrowing(t *testing.T) {
+	var n Narrow
+	if err := WideToNarrow(&n, &Wide{N: -128, U: 1<<31 - 1, I: 7, F: 0.5, G: -3}); err != nil || n != (Narrow{-128, 1<<31 - 1, 7, 0.5, -3}) {
+		t.Fatalf("in range: %+v %v", n, err)
+	}
+	for _, w := range []Wide{{N: 300}, {U: 1 << 31}, {I: -1}, {F: 1e39}, {G: 1.5}, {G: 1e19}} {
+		var ce *copier.CopyError
+		if err := WideToNarrow(&Narrow{}, &w); !errors.As(err, &ce) || !errors.Is(err, copier.ErrOverflow) {
+			t.Fatalf("%+v: expected ErrOverflow, got %v", w, err)
+		}
+	}
+}
+`
+)
+
+// numbers that don't fit their destination fail as with copier.Copy
+func Test_GenerateNumbers(t *testing.T) {
+	dir, got := generatePackage(t, "numbers", narrowing, pairList{{"Wide", "Narrow"}})
+	if err := os.WriteFile(filepath.Join(dir, "numbers_test.go"), []byte(narrowingTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("go", "test", "./"+dir).CombinedOutput(); err != nil {
+		t.Fatalf("go test: %v\n%s\n%s", err, out, got)
+	}
+}
@@ -0,0 +1,74 @@
+// Command structcopy-gen writes reflection free copy functions between pairs of
+// struct types, following the rules of copier.Copy with its default options:
+// copier tags, the default field matching, wrapperspb values, primitive.ObjectID
+// hex strings, times and timestamps, nested structs and slices. Generation
+// fails when a destination field has no source or its types don't convert,
+// unless the field is listed with -ignore.
+//
+// It is meant to run from a go:generate directive, type names are resolved
+// through the imports of the file holding the directive:
+//
+//	//go:generate go run github.com/alexwangfufa/struct-copy/cmd/structcopy-gen -pair domain.MaterialGroup,v1.SaveMaterialGroupRequest
+//
+// Every -pair A,B writes an AToB and a BToA function. A qualifier may also be a
+// full import path, e.g. github.com/org/app/domain.MaterialGroup, and a type
+// of the package itself needs no qualifier.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pairList collects the repeated -pair flags
+type pairList [][2]string
+
+func (p *pairList) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *pairList) Set(value string) error {
+	a, b, ok := strings.Cut(value, ",")
+	if !ok || a == "" || b == "" {
+		return fmt.Errorf("pair %q should be TypeA,TypeB", value)
+	}
+	*p = append(*p, [2]string{strings.TrimSpace(a), strings.TrimSpace(b)})
+	return nil
+}
+
+func main() {
+	var (
+		pairs  pairList
+		file   = flag.String("file", os.Getenv("GOFILE"), "Go file whose imports resolve the type names, defaults to $GOFILE")
+		output = flag.String("o", "", "output file, defaults to <file>_structcopy.go")
+		ignore = flag.String("ignore", "", "comma separated destination fields left out, Field or Type.Field")
+	)
+	flag.Var(&pairs, "pair", "TypeA,TypeB pair to generate both copy functions for, repeatable")
+	flag.Parse()
+
+	if *file == "" || len(pairs) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*file, ".go") + "_structcopy.go"
+	}
+
+	var ignored []string
+	if *ignore != "" {
+		ignored = strings.Split(*ignore, ",")
+	}
+
+	src, err := Generate(*file, pairs, ignored)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "structcopy-gen: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Clean(*output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "structcopy-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -24,8 +24,8 @@ var types map[MaterialGroupType]byte
 var scopes map[MaterialGroupScope]byte
 
 func init() {
-	types := make(map[MaterialGroupType]byte)
-	scopes := make(map[MaterialGroupScope]byte)
+	types = make(map[MaterialGroupType]byte)
+	scopes = make(map[MaterialGroupScope]byte)
 	types[Null] = 0
 	types[Welcome] = 0
 	types[OrganizationTemplate] = 0
@@ -0,0 +1,51 @@
+// Package mapper shows structcopy-gen, the copy functions in
+// mapper_structcopy.go are generated from the directive below.
+package mapper
+
+import (
+	"github.com/alexwangfufa/struct-copy/example/api/material-group/v1"
+	"github.com/alexwangfufa/struct-copy/example/domain"
+	"github.com/alexwangfufa/struct-copy/pkg/copier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate go run github.com/alexwangfufa/struct-copy/cmd/structcopy-gen -pair domain.MaterialGroup,v1.SaveMaterialGroupRequest -pair MaterialGroupSummary,v1.MaterialGroupModel -pair MaterialGroupPage,v1.MaterialGroupModelList -pair MaterialGroupQuery,v1.MaterialGroupModel
+
+func init() {
+	// 生成的函数与 copier.Copy 都只接受已登记的类型与范围
+	copier.RegisterEnum(domain.Null, domain.Welcome, domain.OrganizationTemplate, domain.SelfTemplate, domain.BroadcastJob)
+	copier.RegisterEnum(domain.Organization, domain.Group, domain.User)
+}
+
+// MaterialGroupSummary 话术组列表项
+type MaterialGroupSummary struct {
+	Id    primitive.ObjectID
+	Name  string
+	Kind  domain.MaterialGroupType `copier:"Type"`
+	Order int64
+}
+
+// MaterialGroupPage 话术组列表
+type MaterialGroupPage struct {
+	Data []*MaterialGroupSummary
+}
+
+// MaterialGroupQuery 话术组查询条件，为空的字段不参与过滤
+type MaterialGroupQuery struct {
+	Id    *string
+	Name  *string
+	Type  *domain.MaterialGroupType
+	Order *int64
+}
+
+// SaveRequests 将话术组转换为保存请求
+func SaveRequests(groups []*domain.MaterialGroup) ([]*v1.SaveMaterialGroupRequest, error) {
+	reqs := make([]*v1.SaveMaterialGroupRequest, len(groups))
+	for i, group := range groups {
+		reqs[i] = &v1.SaveMaterialGroupRequest{}
+		if err := MaterialGroupToSaveMaterialGroupRequest(reqs[i], group); err != nil {
+			return nil, err
+		}
+	}
+	return reqs, nil
+}
@@ -0,0 +1,256 @@
+// Code generated by structcopy-gen. DO NOT EDIT.
+
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/alexwangfufa/struct-copy/example/api/material-group/v1"
+	"github.com/alexwangfufa/struct-copy/example/domain"
+	"github.com/alexwangfufa/struct-copy/pkg/copier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// MaterialGroupToSaveMaterialGroupRequest copies src into dst with the rules of copier.Copy
+func MaterialGroupToSaveMaterialGroupRequest(dst *v1.SaveMaterialGroupRequest, src *domain.MaterialGroup) error {
+	if src == nil {
+		return nil
+	}
+	if src.Id != nil {
+		dst.Id = wrapperspb.String(src.Id.Hex())
+	}
+	dst.OrgId = src.OrgId
+	dst.UserId = wrapperspb.String(src.UserId)
+	dst.Name = src.Name
+	dst.IsValid = wrapperspb.Bool(src.IsValid)
+	dst.It = wrapperspb.Int32(src.It)
+	dst.Ut32 = wrapperspb.UInt32(src.Ut32)
+	dst.Ut64 = wrapperspb.UInt64(src.Ut64)
+	dst.StoryPoint = wrapperspb.Double(src.StoryPoint)
+	dst.Point = wrapperspb.Float(src.Point)
+	dst.Type = wrapperspb.String(string(src.Type))
+	dst.Scope = wrapperspb.String(string(src.Scope))
+	dst.Order = wrapperspb.Int64(src.Order)
+	if t := src.UpdateTime; t.IsZero() {
+		dst.UpdateTime = nil
+	} else {
+		ts := timestamppb.New(t)
+		if err := ts.CheckValid(); err != nil {
+			return &copier.CopyError{Path: "UpdateTime", SrcType: reflect.TypeOf(src.UpdateTime), DstType: reflect.TypeOf(dst.UpdateTime), Value: src.UpdateTime, Err: fmt.Errorf("%w: %v", copier.ErrTimeRange, err)}
+		}
+		dst.UpdateTime = ts
+	}
+	if t := src.CreateTime; t.IsZero() {
+		dst.CreateTime = nil
+	} else {
+		ts := timestamppb.New(t)
+		if err := ts.CheckValid(); err != nil {
+			return &copier.CopyError{Path: "CreateTime", SrcType: reflect.TypeOf(src.CreateTime), DstType: reflect.TypeOf(dst.CreateTime), Value: src.CreateTime, Err: fmt.Errorf("%w: %v", copier.ErrTimeRange, err)}
+		}
+		dst.CreateTime = ts
+	}
+	return nil
+}
+
+// SaveMaterialGroupRequestToMaterialGroup copies src into dst with the rules of copier.Copy
+func SaveMaterialGroupRequestToMaterialGroup(dst *domain.MaterialGroup, src *v1.SaveMaterialGroupRequest) error {
+	if src == nil {
+		return nil
+	}
+	if src.Id != nil {
+		if hex := src.Id.GetValue(); hex != "" {
+			id, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				return &copier.CopyError{Path: "Id", SrcType: reflect.TypeOf(src.Id), DstType: reflect.TypeOf(dst.Id), Value: src.Id, Err: err}
+			}
+			if id.IsZero() {
+				dst.Id = nil
+			} else {
+				dst.Id = &id
+			}
+		}
+	}
+	if src.Ut64 != nil {
+		dst.Ut64 = src.Ut64.GetValue()
+	}
+	dst.OrgId = src.OrgId
+	if src.UserId != nil {
+		dst.UserId = src.UserId.GetValue()
+	}
+	if src.Ut32 != nil {
+		dst.Ut32 = src.Ut32.GetValue()
+	}
+	dst.Name = src.Name
+	if src.Type != nil {
+		if err := copier.CheckEnum(domain.MaterialGroupType(src.Type.GetValue())); err != nil {
+			return &copier.CopyError{Path: "Type", SrcType: reflect.TypeOf(src.Type), DstType: reflect.TypeOf(dst.Type), Value: src.Type, Err: err}
+		}
+		dst.Type = domain.MaterialGroupType(src.Type.GetValue())
+	}
+	if src.Scope != nil {
+		if err := copier.CheckEnum(domain.MaterialGroupScope(src.Scope.GetValue())); err != nil {
+			return &copier.CopyError{Path: "Scope", SrcType: reflect.TypeOf(src.Scope), DstType: reflect.TypeOf(dst.Scope), Value: src.Scope, Err: err}
+		}
+		dst.Scope = domain.MaterialGroupScope(src.Scope.GetValue())
+	}
+	if src.Order != nil {
+		dst.Order = src.Order.GetValue()
+	}
+	if src.It != nil {
+		dst.It = src.It.GetValue()
+	}
+	if src.IsValid != nil {
+		dst.IsValid = src.IsValid.GetValue()
+	}
+	if src.StoryPoint != nil {
+		dst.StoryPoint = src.StoryPoint.GetValue()
+	}
+	if src.Point != nil {
+		dst.Point = src.Point.GetValue()
+	}
+	if src.CreateTime != nil {
+		if err := src.CreateTime.CheckValid(); err != nil {
+			return &copier.CopyError{Path: "CreateTime", SrcType: reflect.TypeOf(src.CreateTime), DstType: reflect.TypeOf(dst.CreateTime), Value: src.CreateTime, Err: fmt.Errorf("%w: %v", copier.ErrTimeRange, err)}
+		}
+		dst.CreateTime = src.CreateTime.AsTime()
+	}
+	if src.UpdateTime != nil {
+		if err := src.UpdateTime.CheckValid(); err != nil {
+			return &copier.CopyError{Path: "UpdateTime", SrcType: reflect.TypeOf(src.UpdateTime), DstType: reflect.TypeOf(dst.UpdateTime), Value: src.UpdateTime, Err: fmt.Errorf("%w: %v", copier.ErrTimeRange, err)}
+		}
+		dst.UpdateTime = src.UpdateTime.AsTime()
+	}
+	return nil
+}
+
+// MaterialGroupSummaryToMaterialGroupModel copies src into dst with the rules of copier.Copy
+func MaterialGroupSummaryToMaterialGroupModel(dst *v1.MaterialGroupModel, src *MaterialGroupSummary) error {
+	if src == nil {
+		return nil
+	}
+	dst.Id = src.Id.Hex()
+	dst.Name = src.Name
+	dst.Type = string(src.Kind)
+	dst.Order = src.Order
+	return nil
+}
+
+// MaterialGroupModelToMaterialGroupSummary copies src into dst with the rules of copier.Copy
+func MaterialGroupModelToMaterialGroupSummary(dst *MaterialGroupSummary, src *v1.MaterialGroupModel) error {
+	if src == nil {
+		return nil
+	}
+	if hex := src.Id; hex != "" {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return &copier.CopyError{Path: "Id", SrcType: reflect.TypeOf(src.Id), DstType: reflect.TypeOf(dst.Id), Value: src.Id, Err: err}
+		}
+		dst.Id = id
+	}
+	dst.Name = src.Name
+	if err := copier.CheckEnum(domain.MaterialGroupType(src.Type)); err != nil {
+		return &copier.CopyError{Path: "Kind", SrcType: reflect.TypeOf(src.Type), DstType: reflect.TypeOf(dst.Kind), Value: src.Type, Err: err}
+	}
+	dst.Kind = domain.MaterialGroupType(src.Type)
+	dst.Order = src.Order
+	return nil
+}
+
+// MaterialGroupPageToMaterialGroupModelList copies src into dst with the rules of copier.Copy
+func MaterialGroupPageToMaterialGroupModelList(dst *v1.MaterialGroupModelList, src *MaterialGroupPage) error {
+	if src == nil {
+		return nil
+	}
+	if src.Data != nil {
+		dst.Data = make([]*v1.MaterialGroupModel, len(src.Data))
+		for i := range src.Data {
+			if src.Data[i] != nil {
+				if dst.Data[i] == nil {
+					dst.Data[i] = new(v1.MaterialGroupModel)
+				}
+				if err := MaterialGroupSummaryToMaterialGroupModel(dst.Data[i], src.Data[i]); err != nil {
+					if ce, ok := err.(*copier.CopyError); ok {
+						ce.Path = fmt.Sprintf("Data[%d]", i) + "." + ce.Path
+					}
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MaterialGroupModelListToMaterialGroupPage copies src into dst with the rules of copier.Copy
+func MaterialGroupModelListToMaterialGroupPage(dst *MaterialGroupPage, src *v1.MaterialGroupModelList) error {
+	if src == nil {
+		return nil
+	}
+	if src.Data != nil {
+		dst.Data = make([]*MaterialGroupSummary, len(src.Data))
+		for i := range src.Data {
+			if src.Data[i] != nil {
+				if dst.Data[i] == nil {
+					dst.Data[i] = new(MaterialGroupSummary)
+				}
+				if err := MaterialGroupModelToMaterialGroupSummary(dst.Data[i], src.Data[i]); err != nil {
+					if ce, ok := err.(*copier.CopyError); ok {
+						ce.Path = fmt.Sprintf("Data[%d]", i) + "." + ce.Path
+					}
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MaterialGroupQueryToMaterialGroupModel copies src into dst with the rules of copier.Copy
+func MaterialGroupQueryToMaterialGroupModel(dst *v1.MaterialGroupModel, src *MaterialGroupQuery) error {
+	if src == nil {
+		return nil
+	}
+	if src.Id != nil {
+		dst.Id = *src.Id
+	}
+	if src.Name != nil {
+		dst.Name = *src.Name
+	}
+	if src.Type != nil {
+		dst.Type = string(*src.Type)
+	}
+	if src.Order != nil {
+		dst.Order = *src.Order
+	}
+	return nil
+}
+
+// MaterialGroupModelToMaterialGroupQuery copies src into dst with the rules of copier.Copy
+func MaterialGroupModelToMaterialGroupQuery(dst *MaterialGroupQuery, src *v1.MaterialGroupModel) error {
+	if src == nil {
+		return nil
+	}
+	{
+		v := src.Id
+		dst.Id = &v
+	}
+	{
+		v := src.Name
+		dst.Name = &v
+	}
+	{
+		var v domain.MaterialGroupType
+		if err := copier.CheckEnum(domain.MaterialGroupType(src.Type)); err != nil {
+			return &copier.CopyError{Path: "Type", SrcType: reflect.TypeOf(src.Type), DstType: reflect.TypeOf(v), Value: src.Type, Err: err}
+		}
+		v = domain.MaterialGroupType(src.Type)
+		dst.Type = &v
+	}
+	{
+		v := src.Order
+		dst.Order = &v
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package mapper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexwangfufa/struct-copy/example/api/material-group/v1"
+	"github.com/alexwangfufa/struct-copy/example/domain"
+	"github.com/alexwangfufa/struct-copy/pkg/copier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// the generated functions should give the same result as copier.Copy
+func Test_GeneratedMatchesCopy(t *testing.T) {
+	id := primitive.NewObjectID()
+	now := time.Now().UTC()
+	name := "name"
+
+	tests := []struct {
+		name   string
+		src    interface{}
+		copied interface{}
+	}{
+		{
+			name: "domain to request",
+			src: &domain.MaterialGroup{Id: &id, Ut64: 64, OrgId: "org", UserId: "user", Ut32: 32, Name: "name",
+				Type: domain.Welcome, Scope: domain.Group, Order: 1, It: 2, IsValid: true, StoryPoint: 1.5, Point: 2.5, UpdateTime: now},
+			copied: &v1.SaveMaterialGroupRequest{},
+		},
+		{
+			name: "request to domain",
+			src: &v1.SaveMaterialGroupRequest{Id: wrapperspb.String(id.Hex()), OrgId: "org", UserId: wrapperspb.String("user"),
+				Name: "name", IsValid: wrapperspb.Bool(true), It: wrapperspb.Int32(2), Ut32: wrapperspb.UInt32(32),
+				Type: wrapperspb.String("welcome"), Scope: wrapperspb.String("org"), Order: wrapperspb.Int64(1), CreateTime: timestamppb.New(now)},
+			copied: &domain.MaterialGroup{},
+		},
+		{
+			name:   "page to list",
+			src:    &MaterialGroupPage{Data: []*MaterialGroupSummary{{Id: id, Name: "name", Kind: domain.Welcome, Order: 1}, nil}},
+			copied: &v1.MaterialGroupModelList{},
+		},
+		{
+			name:   "list to page",
+			src:    &v1.MaterialGroupModelList{Data: []*v1.MaterialGroupModel{{Id: id.Hex(), Name: "name", Type: "welcome"}, {}}},
+			copied: &MaterialGroupPage{},
+		},
+		{
+			name:   "query to model",
+			src:    &MaterialGroupQuery{Name: &name, Type: &domain.SelfTemplate},
+			copied: &v1.MaterialGroupModel{},
+		},
+		{
+			name:   "model to query",
+			src:    &v1.MaterialGroupModel{Id: id.Hex(), Type: "welcome"},
+			copied: &MaterialGroupQuery{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := copier.Copy(tt.copied, tt.src); err != nil {
+				t.Fatalf("Copy() error = %v", err)
+			}
+
+			generated := reflect.New(reflect.TypeOf(tt.copied).Elem()).Interface()
+			var err error
+			switch dst := generated.(type) {
+			case *v1.SaveMaterialGroupRequest:
+				err = MaterialGroupToSaveMaterialGroupRequest(dst, tt.src.(*domain.MaterialGroup))
+			case *domain.MaterialGroup:
+				err = SaveMaterialGroupRequestToMaterialGroup(dst, tt.src.(*v1.SaveMaterialGroupRequest))
+			case *v1.MaterialGroupModelList:
+				err = MaterialGroupPageToMaterialGroupModelList(dst, tt.src.(*MaterialGroupPage))
+			case *MaterialGroupPage:
+				err = MaterialGroupModelListToMaterialGroupPage(dst, tt.src.(*v1.MaterialGroupModelList))
+			case *v1.MaterialGroupModel:
+				err = MaterialGroupQueryToMaterialGroupModel(dst, tt.src.(*MaterialGroupQuery))
+			case *MaterialGroupQuery:
+				err = MaterialGroupModelToMaterialGroupQuery(dst, tt.src.(*v1.MaterialGroupModel))
+			}
+			if err != nil {
+				t.Fatalf("generated error = %v", err)
+			}
+			if !reflect.DeepEqual(generated, tt.copied) {
+				t.Fatalf("generated = %+v, Copy = %+v", generated, tt.copied)
+			}
+		})
+	}
+}
+
+func Test_GeneratedError(t *testing.T) {
+	tests := []struct {
+		name string
+		list *v1.MaterialGroupModelList
+		path string
+		err  error
+	}{
+		{
+			name: "bad id",
+			list: &v1.MaterialGroupModelList{Data: []*v1.MaterialGroupModel{{}, {Id: "bad"}}},
+			path: "Data[1].Id",
+		},
+		{
+			name: "unknown type",
+			list: &v1.MaterialGroupModelList{Data: []*v1.MaterialGroupModel{{Type: "welcome"}, {Type: "bad"}}},
+			path: "Data[1].Kind",
+			err:  copier.ErrUnknownEnum,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MaterialGroupModelListToMaterialGroupPage(&MaterialGroupPage{}, tt.list)
+			ce, ok := err.(*copier.CopyError)
+			if !ok || ce.Path != tt.path {
+				t.Fatalf("error = %v, want a *copier.CopyError at %s", err, tt.path)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/alexwangfufa/struct-copy
 
-go 1.17
+go 1.18
 
 require (
 	github.com/envoyproxy/protoc-gen-validate v0.6.3
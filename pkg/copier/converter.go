@@ -0,0 +1,307 @@
+package copier
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// convertFunc writes src into dst, dst is always settable and src is never a nil pointer.
+// s carries the options of the copy.
+type convertFunc func(s *state, dst, src reflect.Value) error
+
+// converter is a named convertFunc, the name shows up in copy reports
+type converter struct {
+	name string
+	fn   convertFunc
+}
+
+func newConverter(src, dst reflect.Type, fn convertFunc) *converter {
+	return &converter{name: src.String() + " -> " + dst.String(), fn: fn}
+}
+
+// typePair identifies a conversion by the exact source and destination types
+type typePair struct {
+	src reflect.Type
+	dst reflect.Type
+}
+
+// ConverterFunc converts src, whose dynamic type is the registered source type,
+// into a value assignable to the registered destination type.
+// Returning a nil value sets the destination to its zero value.
+type ConverterFunc func(src interface{}) (interface{}, error)
+
+var (
+	// converters holds the built-in rules, it is only written during init
+	converters = make(map[typePair]*converter)
+
+	// userConverters holds the rules added with RegisterConverter
+	userConverters   = make(map[typePair]*converter)
+	userConvertersMu sync.RWMutex
+)
+
+// RegisterConverter adds fn as the conversion from srcType to dstType.
+// Registered converters take precedence over the built-in rules and are used
+// wherever the pair shows up, registering the same pair again replaces fn.
+// It is safe to call RegisterConverter concurrently with Copy.
+func RegisterConverter(srcType, dstType reflect.Type, fn ConverterFunc) {
+	if srcType == nil || dstType == nil || fn == nil {
+		panic("copier: RegisterConverter with nil type or func")
+	}
+
+	userConvertersMu.Lock()
+	defer userConvertersMu.Unlock()
+	defer resetCaches()
+	userConverters[typePair{src: srcType, dst: dstType}] = newConverter(srcType, dstType, func(_ *state, dst, src reflect.Value) error {
+		out, err := fn(src.Interface())
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			dst.Set(reflect.Zero(dstType))
+			return nil
+		}
+		v := reflect.ValueOf(out)
+		if !v.Type().AssignableTo(dstType) {
+			return errors.Errorf("converter for %s -> %s returned %s", srcType, dstType, v.Type())
+		}
+		dst.Set(v)
+		return nil
+	})
+}
+
+func register(src, dst reflect.Type, fn convertFunc) {
+	converters[typePair{src: src, dst: dst}] = newConverter(src, dst, fn)
+}
+
+func lookupConverter(src, dst reflect.Type) *converter {
+	pair := typePair{src: src, dst: dst}
+
+	userConvertersMu.RLock()
+	c, ok := userConverters[pair]
+	userConvertersMu.RUnlock()
+	if ok {
+		return c
+	}
+
+	return converters[pair]
+}
+
+// baseTypes are the unnamed types of the basic kinds
+var baseTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(0),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+// baseType returns the unnamed type of a named basic type such as
+// domain.MaterialGroupType, other types are returned as they are
+func baseType(t reflect.Type) reflect.Type {
+	if base, ok := baseTypes[t.Kind()]; ok {
+		return base
+	}
+	return t
+}
+
+// ownRules are the named basic types with conversions of their own, which
+// the converters of their base type must not take over
+var ownRules = map[reflect.Type]bool{durationType: true, dateTime: true}
+
+// baseConverter adapts the built-in wrapper or ObjectID converter between the
+// base types of src and dst, nil when neither is a named basic type or the base
+// types have no such converter. Converters added with RegisterConverter only
+// apply to their exact pair, and time.Duration and primitive.DateTime keep
+// their own rules.
+func baseConverter(src, dst reflect.Type) *converter {
+	if ownRules[src] || ownRules[dst] {
+		return nil
+	}
+	srcBase, dstBase := baseType(src), baseType(dst)
+	if srcBase == src && dstBase == dst {
+		return nil
+	}
+	if !isWrapperOrID(srcBase) && !isWrapperOrID(dstBase) {
+		return nil
+	}
+	c := converters[typePair{src: srcBase, dst: dstBase}]
+	if c == nil {
+		return nil
+	}
+	return newConverter(src, dst, func(s *state, dst, src reflect.Value) error {
+		if dstBase == dst.Type() {
+			return c.fn(s, dst, src.Convert(srcBase))
+		}
+		// a converter may leave dst untouched, so it starts from the current value
+		out := reflect.New(dstBase).Elem()
+		out.Set(dst.Convert(dstBase))
+		if err := c.fn(s, out, src.Convert(srcBase)); err != nil {
+			return err
+		}
+		dst.Set(out.Convert(dst.Type()))
+		return nil
+	})
+}
+
+func init() {
+	// every wrapperspb message <-> the type it wraps, and a pointer to it
+	for _, w := range wrapperTypes {
+		registerWrapper(w)
+	}
+
+	// primitive.ObjectID <-> string and StringValue as a hex string, and []byte as its 12 bytes
+	for _, idType := range []reflect.Type{objectID, objectIDPtr} {
+		register(idType, stringType, func(_ *state, dst, src reflect.Value) error {
+			dst.SetString(objectIDOf(src).Hex())
+			return nil
+		})
+		register(idType, stringValue, func(_ *state, dst, src reflect.Value) error {
+			dst.Set(reflect.ValueOf(wrapperspb.String(objectIDOf(src).Hex())))
+			return nil
+		})
+		register(idType, bytesType, func(_ *state, dst, src reflect.Value) error {
+			id := objectIDOf(src)
+			dst.SetBytes(append([]byte(nil), id[:]...))
+			return nil
+		})
+		register(stringType, idType, func(_ *state, dst, src reflect.Value) error {
+			return setObjectIDFromHex(dst, src.String())
+		})
+		register(stringValue, idType, func(_ *state, dst, src reflect.Value) error {
+			return setObjectIDFromHex(dst, src.Interface().(*wrapperspb.StringValue).GetValue())
+		})
+		register(bytesType, idType, func(_ *state, dst, src reflect.Value) error {
+			return setObjectIDFromBytes(dst, src.Bytes())
+		})
+	}
+
+	// primitive.ObjectID <-> *primitive.ObjectID, the zero id becomes nil
+	register(objectID, objectIDPtr, func(_ *state, dst, src reflect.Value) error {
+		setObjectID(dst, objectIDOf(src))
+		return nil
+	})
+	register(objectIDPtr, objectID, func(_ *state, dst, src reflect.Value) error {
+		dst.Set(src.Elem())
+		return nil
+	})
+}
+
+// wrapperTypes are the wrapperspb messages, each holds its value in a Value field
+var wrapperTypes = []reflect.Type{
+	doubleValue, floatValue, int64Value, uint64Value, int32Value, uint32Value, boolValue, stringValue, bytesValue,
+}
+
+// registerWrapper registers the conversions of the wrapper pointer type w
+// with T and *T, T being the type of its Value field
+func registerWrapper(w reflect.Type) {
+	field, _ := w.Elem().FieldByName("Value")
+	value, ptr := field.Type, reflect.PtrTo(field.Type)
+
+	register(w, value, func(_ *state, dst, src reflect.Value) error {
+		dst.Set(cloneBytes(src.Elem().FieldByIndex(field.Index)))
+		return nil
+	})
+	register(w, ptr, func(_ *state, dst, src reflect.Value) error {
+		p := reflect.New(value)
+		p.Elem().Set(cloneBytes(src.Elem().FieldByIndex(field.Index)))
+		dst.Set(p)
+		return nil
+	})
+	register(value, w, func(s *state, dst, src reflect.Value) error {
+		if s.zeroWrapperToNil() && (src.IsZero() || src.Kind() == reflect.Slice && src.Len() == 0) {
+			dst.Set(reflect.Zero(w))
+			return nil
+		}
+		out := reflect.New(w.Elem())
+		out.Elem().FieldByIndex(field.Index).Set(cloneBytes(src))
+		dst.Set(out)
+		return nil
+	})
+	register(ptr, w, func(_ *state, dst, src reflect.Value) error {
+		out := reflect.New(w.Elem())
+		out.Elem().FieldByIndex(field.Index).Set(cloneBytes(src.Elem()))
+		dst.Set(out)
+		return nil
+	})
+}
+
+// isWrapperOrID reports whether t is a wrapperspb message pointer, a
+// primitive.ObjectID or a pointer to one
+func isWrapperOrID(t reflect.Type) bool {
+	for _, w := range wrapperTypes {
+		if t == w {
+			return true
+		}
+	}
+	return t == objectID || t == objectIDPtr
+}
+
+// cloneBytes copies a []byte value so that the source and destination don't
+// share it, other values are returned as they are
+func cloneBytes(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		return v
+	}
+	return reflect.ValueOf(append([]byte(nil), v.Bytes()...))
+}
+
+// objectIDOf returns the id held by an ObjectID or a non nil *ObjectID
+func objectIDOf(v reflect.Value) primitive.ObjectID {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v.Interface().(primitive.ObjectID)
+}
+
+// setObjectID stores id into an ObjectID or *ObjectID destination, a pointer
+// is set to nil for the zero id
+func setObjectID(dst reflect.Value, id primitive.ObjectID) {
+	switch {
+	case dst.Kind() != reflect.Ptr:
+		dst.Set(reflect.ValueOf(id))
+	case id.IsZero():
+		dst.Set(reflect.Zero(dst.Type()))
+	default:
+		dst.Set(reflect.ValueOf(&id))
+	}
+}
+
+// setObjectIDFromHex parses s and stores it into an ObjectID or *ObjectID destination,
+// an empty string means there is no id and leaves dst untouched
+func setObjectIDFromHex(dst reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		return err
+	}
+	setObjectID(dst, id)
+	return nil
+}
+
+// setObjectIDFromBytes stores the 12 bytes of b into an ObjectID or *ObjectID
+// destination, an empty b means there is no id and leaves dst untouched
+func setObjectIDFromBytes(dst reflect.Value, b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	if len(b) != len(primitive.ObjectID{}) {
+		return errors.Errorf("object id should be %d bytes, got %d", len(primitive.ObjectID{}), len(b))
+	}
+	setObjectID(dst, *(*primitive.ObjectID)(b))
+	return nil
+}
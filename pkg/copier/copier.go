@@ -1,251 +1,756 @@
 package copier
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
+	"math"
 	"reflect"
-	"runtime"
+	"sort"
+	"strconv"
 	"time"
 )
 
 var (
-	stringValue = reflect.TypeOf(wrapperspb.StringValue{}).Kind()
-	int64Value  = reflect.TypeOf(wrapperspb.Int64Value{}).Kind()
-	int32Value  = reflect.TypeOf(wrapperspb.Int32Value{}).Kind()
-	doubleValue = reflect.TypeOf(wrapperspb.DoubleValue{}).Kind()
-	floatValue  = reflect.TypeOf(wrapperspb.FloatValue{}).Kind()
-	uint32Value = reflect.TypeOf(wrapperspb.UInt32Value{}).Kind()
-	uint64Value = reflect.TypeOf(wrapperspb.UInt64Value{}).Kind()
-	boolValue   = reflect.TypeOf(wrapperspb.BoolValue{}).Kind()
-	objectID    = reflect.TypeOf(primitive.ObjectID{}).Kind()
-	pbTimestamp = reflect.TypeOf(timestamppb.Timestamp{}).Kind()
-	timestamp   = reflect.TypeOf(time.Time{}).Kind()
+	stringValue  = reflect.TypeOf(&wrapperspb.StringValue{})
+	int64Value   = reflect.TypeOf(&wrapperspb.Int64Value{})
+	int32Value   = reflect.TypeOf(&wrapperspb.Int32Value{})
+	doubleValue  = reflect.TypeOf(&wrapperspb.DoubleValue{})
+	floatValue   = reflect.TypeOf(&wrapperspb.FloatValue{})
+	uint32Value  = reflect.TypeOf(&wrapperspb.UInt32Value{})
+	uint64Value  = reflect.TypeOf(&wrapperspb.UInt64Value{})
+	boolValue    = reflect.TypeOf(&wrapperspb.BoolValue{})
+	bytesValue   = reflect.TypeOf(&wrapperspb.BytesValue{})
+	objectID     = reflect.TypeOf(primitive.ObjectID{})
+	objectIDPtr  = reflect.TypeOf(&primitive.ObjectID{})
+	pbTimestamp  = reflect.TypeOf(&timestamppb.Timestamp{})
+	timestamp    = reflect.TypeOf(time.Time{})
+	timestampPtr = reflect.TypeOf(&time.Time{})
+	dateTime     = reflect.TypeOf(primitive.DateTime(0))
+	durationType = reflect.TypeOf(time.Duration(0))
+	pbDuration   = reflect.TypeOf(&durationpb.Duration{})
+
+	stringType = reflect.TypeOf("")
+	int64Type  = reflect.TypeOf(int64(0))
+	bytesType  = reflect.TypeOf([]byte(nil))
 )
 
-func Copy(dst, src interface{}) (err error) {
-	defer func() {
-		// 发生宕机时，获取panic传递的上下文并打印
-		err := recover()
-		if err != nil {
-			switch err.(type) {
-			case runtime.Error: // 运行时错误
-				err = errors.Cause(err.(runtime.Error))
-			default: // 非运行时错误
+// Copy copies the fields of src into the struct pointed to by dst.
+// A failing field is reported as a *CopyError, panics raised while copying are
+// recovered and returned the same way.
+func Copy(dst, src interface{}, opts ...Option) error {
+	return run(newState(opts), dst, src, copy)
+}
+
+// state carries the options, the collected errors and the report of one Copy call
+type state struct {
+	opts   *options
+	errs   Errors
+	report *Report
+	// zero is the zero= tag of the field being copied
+	zero zeroTag
+	// visiting holds the source struct pointers being copied, see copyNested
+	visiting map[visit]bool
+}
+
+// visit is a source struct pointer, the type tells a struct from its first field
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
 
-				err = errors.Cause(err.(error))
+func newState(opts []Option) *state {
+	return &state{opts: newOptions(opts)}
+}
+
+// zeroWrapperToNil reports whether a zero value becomes a nil wrapper
+func (s *state) zeroWrapperToNil() bool {
+	if s.zero != zeroUnset {
+		return s.zero == zeroNil
+	}
+	return s.opts.zeroWrappers == ZeroWrapperToNil
+}
+
+// zeroPointerToNil reports whether a zero value becomes a nil scalar pointer
+func (s *state) zeroPointerToNil() bool {
+	if s.zero != zeroUnset {
+		return s.zero == zeroNil
+	}
+	return s.opts.zeroPointers == ZeroPointerToNil
+}
+
+// zeroTimeToNil reports whether a zero time becomes a nil pointer
+func (s *state) zeroTimeToNil() bool {
+	if s.zero != zeroUnset {
+		return s.zero == zeroNil
+	}
+	return s.opts.zeroTimes == ZeroTimeToNil
+}
+
+// fail records a field error, it returns the error to stop at unless errors are collected
+func (s *state) fail(err error) error {
+	if ce, ok := err.(*CopyError); ok && s.opts.collectErrors {
+		s.errs = append(s.errs, ce)
+		return nil
+	}
+	return err
+}
+
+// run copies src into dst with fn and returns the outcome for s
+func run(s *state, dst, src interface{}, fn func(s *state, dst, src interface{}) error) (err error) {
+	defer func() {
+		// 发生宕机时，获取panic传递的上下文并返回
+		if r := recover(); r != nil {
+			err = &CopyError{
+				DstType: reflect.TypeOf(dst),
+				SrcType: reflect.TypeOf(src),
+				Value:   src,
+				Err:     panicError(r),
 			}
 		}
 	}()
-	return copy(dst, src)
+
+	if err := fn(s, dst, src); err != nil {
+		return err
+	}
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return s.errs
 }
 
-func copy(dst, src interface{}) error {
+func copy(s *state, dst, src interface{}) error {
+	return copyRoot(s, dst, src, nil)
+}
 
+// copyRoot checks dst and src and copies src into dst with the mapping m, nil
+// resolves it from the cache
+func copyRoot(s *state, dst, src interface{}, m *structMapping) error {
 	dstType, dstValue := reflect.TypeOf(dst), reflect.ValueOf(dst)
 	srcType, srcValue := reflect.TypeOf(src), reflect.ValueOf(src)
 
-	if dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Struct {
+	if dstType == nil || dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Struct {
 		return errors.New("dest type should be a struct pointer")
 	}
+	if dstValue.IsNil() {
+		return errors.New("dest should be a non nil struct pointer")
+	}
 
-	if srcType.Kind() == reflect.Ptr {
+	if srcType != nil && srcType.Kind() == reflect.Ptr {
+		if srcValue.IsNil() {
+			return errors.New("src should be a non nil struct pointer")
+		}
+		// src itself may be met again below, see copyNested
+		s.visiting = map[visit]bool{{ptr: srcValue.Pointer(), typ: srcType}: true}
 		srcType, srcValue = srcType.Elem(), srcValue.Elem()
 	}
 
-	if srcType.Kind() != reflect.Struct {
+	if srcType == nil || srcType.Kind() != reflect.Struct {
 		return errors.New("src type should be a struct pointer")
 	}
 
 	// 取具体的值
-	dstType, dstValue = dstType.Elem(), dstValue.Elem()
+	dstValue = dstValue.Elem()
+	if s.opts.dryRun {
+		dstValue = reflect.New(dstValue.Type()).Elem()
+	}
+	if m == nil {
+		m = planFor(dstValue.Type(), srcValue.Type(), s.opts)
+	}
+	return copyMapping(s, m, dstValue, srcValue, "")
+}
 
-	fieldNum := dstType.NumField()
+// copyStruct copies every exported field of dst from its matching src field, see mapStruct
+func copyStruct(s *state, dst, src reflect.Value, path string) error {
+	return copyMapping(s, planFor(dst.Type(), src.Type(), s.opts), dst, src, path)
+}
 
-	for i := 0; i < fieldNum; i++ {
-		fieldType := dstType.Field(i)
-		// 找到src中与dst相同字段的value
-		fieldValue := srcValue.FieldByName(fieldType.Name)
+// copyMapping copies the fields of src into dst as m pairs them
+func copyMapping(s *state, m *structMapping, dst, src reflect.Value, path string) error {
+	// oneofs holds the name of the field that set each oneof of dst
+	var oneofs map[string]string
 
-		dstFieldValue := dstValue.Field(i)
+	for _, fm := range m.fields {
+		name := fm.dst.Name
+		fieldPath := joinPath(path, name)
+		dstField := dst.FieldByIndex(fm.dst.Index)
 
-		// 无效, 说明src没有这个属性
-		if !fieldValue.IsValid() {
+		if fm.ignored {
+			s.record(fieldPath, dstField, reflect.Value{}, FieldReport{Action: Skipped, Reason: ReasonIgnored})
 			continue
 		}
 
-		// 如果是指针类型,并且为nil,不处理
-		if fieldValue.Kind() == reflect.Ptr {
-			if fieldValue.IsNil() {
+		// 无效, 说明src没有这个属性
+		if !fm.hasSrc {
+			if s.opts.requireSource.enforced(name, fieldPath) {
+				err := newCopyError(fieldPath, dstField, reflect.Value{}, ErrNoSource)
+				s.record(fieldPath, dstField, reflect.Value{}, FieldReport{Action: Failed, Err: err})
+				if err = s.fail(err); err != nil {
+					return err
+				}
 				continue
 			}
-			fieldValue = fieldValue.Elem()
+			s.record(fieldPath, dstField, reflect.Value{}, FieldReport{Action: Skipped, Reason: ReasonNoSource})
+			continue
+		}
+		fieldValue := sourceField(src, fm.src.Index)
+		if fm.srcCase != nil {
+			value, ok := fm.srcCase.get(fieldValue)
+			if !ok {
+				s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Skipped, Reason: ReasonOneofUnset})
+				continue
+			}
+			fieldValue = value
 		}
 
-		switch fieldValue.Kind() {
-		// StringValue to string, if srcField name is id, StringValue to primitive.ObjectID
-		case stringValue:
-			value := fieldValue.FieldByName("Value").String()
-
-			if dstFieldValue.Kind() == reflect.String {
-				dstFieldValue.SetString(value)
+		// 如果是指针类型,并且为nil,不处理
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+			s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Skipped, Reason: ReasonNilPointer})
+			continue
+		}
+		if fieldValue.Kind() == reflect.Slice && fieldValue.IsNil() {
+			s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Skipped, Reason: ReasonNilSlice})
+			continue
+		}
+		if fieldValue.Kind() == reflect.Map && fieldValue.IsNil() {
+			if setNilMap(s, dstField) {
+				s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Copied})
 			} else {
-				id, err := primitive.ObjectIDFromHex(value)
-				if err != nil {
+				s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Skipped, Reason: ReasonNilMap})
+			}
+			continue
+		}
+
+		// a oneof is set by one field at most, zero values don't set it
+		var before interface{}
+		if c := fm.rule.oneof; c != nil {
+			if fieldValue.IsZero() {
+				s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Skipped, Reason: ReasonOneofUnset})
+				continue
+			}
+			if other, ok := oneofs[c.field.Name]; ok {
+				err := newCopyError(fieldPath, dstField, fieldValue, errors.Wrapf(ErrOneof, "%s is already set by %s", c.field.Name, other))
+				s.record(fieldPath, dstField, fieldValue, FieldReport{Action: Failed, Err: err})
+				if err = s.fail(err); err != nil {
 					return err
 				}
-				dstFieldValue.Set(reflect.ValueOf(&id))
+				continue
 			}
+			before = dstField.Interface()
+		}
 
-		// Int64Value to int64
-		case int64Value:
-			value := fieldValue.FieldByName("Value").Int()
-			dstFieldValue.Set(reflect.ValueOf(value))
-
-		// Int32Value to int32
-		case int32Value:
-			value := fieldValue.FieldByName("Value").Int()
-			dstFieldValue.Set(reflect.ValueOf(int32(value)))
-
-		// UInt64Value to uint64
-		case uint64Value:
-			value := fieldValue.FieldByName("Value").Int()
-			dstFieldValue.Set(reflect.ValueOf(uint64(value)))
-
-		// UInt32Value to uint32
-		case uint32Value:
-			value := fieldValue.FieldByName("Value").Int()
-			dstFieldValue.Set(reflect.ValueOf(uint32(value)))
-
-		// DoubleValue and FloatValue to float
-		case doubleValue, floatValue:
-			value := fieldValue.FieldByName("Value").Float()
-			dstFieldValue.Set(reflect.ValueOf(value))
-
-		// BoolValue to bool
-		case boolValue:
-			value := fieldValue.FieldByName("Value").Bool()
-			dstFieldValue.Set(reflect.ValueOf(value))
-
-		// timestamppb to time.Time
-		case pbTimestamp:
-			dstFieldValue.Set(reflect.ValueOf(fieldValue))
-
-		// primitive.ObjectID to StringValue or string
-		case objectID:
-			value := fieldValue.MethodByName("Hex").Call(nil)
-			if dstFieldValue.Kind() == reflect.String {
-				dstFieldValue.Set(reflect.ValueOf(value[0].String()))
-			} else {
-				id := &wrapperspb.StringValue{Value: value[0].String()}
-				dstFieldValue.Set(reflect.ValueOf(id))
+		// a zero= tag holds for the field and everything below it
+		zero := s.zero
+		if fm.zero != zeroUnset {
+			s.zero = fm.zero
+		}
+		err := copyField(s, fm.rule, dstField, fieldValue, name, fieldPath)
+		s.zero = zero
+		if err != nil {
+			if err = s.fail(err); err != nil {
+				return err
 			}
+		}
+		if c := fm.rule.oneof; c != nil && dstField.Interface() != before {
+			if oneofs == nil {
+				oneofs = make(map[string]string)
+			}
+			oneofs[c.field.Name] = name
+		}
+	}
 
-		// string to StringValue or String
-		case reflect.String:
-			value := fieldValue.String()
-			// if dstField type is StringValue
-			if dstFieldValue.Kind() == reflect.String {
-				dstFieldValue.SetString(value)
-			} else if dstFieldValue.Kind() == objectID {
-				id, err := primitive.ObjectIDFromHex(value)
-				if err != nil {
-					return err
-				}
-				dstFieldValue.Set(reflect.ValueOf(id))
-			} else if fieldType.Type.Kind() == reflect.Ptr && fieldType.Type.Elem().Kind() == objectID {
-				id, err := primitive.ObjectIDFromHex(value)
-				if err != nil {
+	if s.opts.requireDestination != nil {
+		for _, f := range m.unused {
+			fieldPath := joinPath(path, f.Name)
+			if s.opts.requireDestination.enforced(f.Name, fieldPath) {
+				if err := s.fail(newCopyError(fieldPath, reflect.Value{}, src.FieldByIndex(f.Index), ErrNoDestination)); err != nil {
 					return err
 				}
-				dstFieldValue.Set(reflect.ValueOf(&id))
-			} else {
-				spbString := &wrapperspb.StringValue{
-					Value: value,
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbString))
 			}
+		}
+	}
 
-		// int64 to int64 or Int64Value
-		case reflect.Int64:
-			value := fieldValue.Int()
-			// if dstFieldValue type is Int64Value
-			if dstFieldValue.Kind() == int64Value {
-				spbInt64 := &wrapperspb.Int64Value{
-					Value: value,
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbInt64))
-			} else {
-				dstFieldValue.Set(reflect.ValueOf(value))
-			}
+	return nil
+}
 
-		// int32 to int32 or Int32
-		case reflect.Int32:
-			value := fieldValue.Int()
-			// if dstFieldValue type is Int32Value
-			if fieldType.Type.Kind() == reflect.Ptr && fieldType.Type.Elem().Kind() == int32Value {
-				spbInt32 := wrapperspb.Int32(int32(value))
-				dstFieldValue.Set(reflect.ValueOf(spbInt32))
-			} else {
-				dstFieldValue.Set(reflect.ValueOf(value))
-			}
+// copyField copies one field with its precomputed rule, turning failures and
+// reflect panics into a *CopyError for path
+func copyField(s *state, r rule, dst, src reflect.Value, name, path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newCopyError(path, dst, src, panicError(r))
+		}
+		if err != nil {
+			s.record(path, dst, src, FieldReport{Action: Failed, Err: err})
+		}
+	}()
 
-		// uint64 to uint64 or UInt64Value
-		case reflect.Uint64:
-			value := fieldValue.Int()
-			// if dstFieldValue type is UInt64Value
-			if dstFieldValue.Kind() == uint64Value {
-				spbUint64 := &wrapperspb.UInt64Value{
-					Value: uint64(value),
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbUint64))
-			} else {
-				dstFieldValue.Set(reflect.ValueOf(value))
+	conv, err := applyRule(s, r, dst, src, path)
+	switch {
+	case err == ErrUnsupported && !s.opts.requireSupported.enforced(name, path):
+		s.record(path, dst, src, FieldReport{Action: Skipped, Reason: ReasonUnsupported})
+		return nil
+	case err != nil:
+		return newCopyError(path, dst, src, err)
+	case conv == "":
+		s.record(path, dst, src, FieldReport{Action: Copied})
+	default:
+		s.record(path, dst, src, FieldReport{Action: Converted, Converter: conv})
+	}
+	return nil
+}
+
+// elementPath is the path of a slice, array or map element below path, it is
+// only built for a failure or a rule that copies below the element
+type elementPath struct {
+	path  string
+	index int
+	// key is the printed key of a map entry, nil for other elements
+	key *string
+}
+
+func (p elementPath) String() string {
+	if p.key != nil {
+		return p.path + "[" + *p.key + "]"
+	}
+	return p.path + "[" + strconv.Itoa(p.index) + "]"
+}
+
+// copyElement copies one slice, array or map element with the rule r of the
+// element types, failures are wrapped for at.
+// A nil pointer element leaves dst at its zero value, a nil map element follows NilMaps.
+func copyElement(s *state, r rule, dst, src reflect.Value, at elementPath) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newCopyError(at.String(), dst, src, panicError(r))
+		}
+	}()
+
+	switch {
+	case src.Kind() == reflect.Ptr && src.IsNil():
+		return nil
+	case src.Kind() == reflect.Map && src.IsNil():
+		setNilMap(s, dst)
+		return nil
+	}
+	var path string
+	if r.nests() {
+		path = at.String()
+	}
+	if _, err := applyRule(s, r, dst, src, path); err != nil {
+		return newCopyError(at.String(), dst, src, err)
+	}
+	return nil
+}
+
+// copyKey converts a map key with the rule r of the key types, failures are
+// wrapped for at. Integer keys and string keys without a rule convert into
+// each other, see isIntegerKeyPair.
+func copyKey(s *state, r rule, dst, src reflect.Value, at elementPath) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newCopyError(at.String(), dst, src, panicError(r))
+		}
+	}()
+
+	if r.kind == ruleUnsupported {
+		err = convertIntegerKey(dst, src)
+	} else {
+		_, err = applyRule(s, r, dst, src, at.String())
+	}
+	if err != nil {
+		return newCopyError(at.String(), dst, src, err)
+	}
+	return nil
+}
+
+// sourceField is src.FieldByIndex, except that a nil embedded pointer on the
+// way is returned in place of the promoted field, which is skipped as nil
+func sourceField(src reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && src.Kind() == reflect.Ptr {
+			if src.IsNil() {
+				return src
 			}
+			src = src.Elem()
+		}
+		src = src.Field(x)
+	}
+	return src
+}
 
-		// uint32 to uint32 or UInt32Value
-		case reflect.Uint32:
-			value := fieldValue.Int()
-			// if dstFieldValue type is UInt32Value
-			if dstFieldValue.Kind() == uint32Value {
-				spbUint32 := &wrapperspb.UInt32Value{
-					Value: uint32(value),
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbUint32))
-			} else {
-				dstFieldValue.Set(reflect.ValueOf(value))
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// copyValue writes src into dst following the rule for their types, see resolveRule.
+// Pairs without any rule leave dst untouched and return ErrUnsupported.
+// The returned name is the conversion used, empty for a plain assignment.
+func copyValue(s *state, dst, src reflect.Value, path string) (string, error) {
+	return applyRule(s, ruleFor(src.Type(), dst.Type()), dst, src, path)
+}
+
+func applyRule(s *state, r rule, dst, src reflect.Value, path string) (string, error) {
+	if r.oneof != nil {
+		return applyOneofCase(s, r, dst, src, path)
+	}
+	if r.enum {
+		return applyEnum(s, r, dst, src, path)
+	}
+	switch r.kind {
+	case ruleConverter:
+		return r.name(), r.conv.fn(s, dst, src)
+	case ruleAssign:
+		dst.Set(src)
+		return r.name(), nil
+	case ruleClone:
+		cloneSlice(dst, src)
+		return r.name(), nil
+	case ruleConvert:
+		if isNumber(src.Kind()) {
+			return r.name(), convertNumber(dst, src)
+		}
+		dst.Set(src.Convert(dst.Type()))
+		return r.name(), nil
+	case ruleNested:
+		return r.name(), copyNested(s, dst, src, path)
+	case ruleSequence:
+		return r.name(), copySequence(s, dst, src, path)
+	case ruleMap:
+		return r.name(), copyMap(s, dst, src, path)
+	case ruleOneof:
+		return r.name(), copyOneof(s, dst, src, path)
+	case rulePointer:
+		return copyPointer(s, dst, src, path)
+	}
+	return "", ErrUnsupported
+}
+
+// isStructLike reports whether t is a struct or a pointer to a struct with exported fields
+func isStructLike(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// copyNested copies a struct or non nil struct pointer src into a struct or struct
+// pointer dst, a nil dst pointer gets a new struct allocated. A src pointer met
+// again while it is being copied fails with ErrCycle.
+func copyNested(s *state, dst, src reflect.Value, path string) error {
+	if src.Kind() == reflect.Ptr {
+		v := visit{ptr: src.Pointer(), typ: src.Type()}
+		if s.visiting[v] {
+			return errors.Wrapf(ErrCycle, "%v refers back to itself", src.Type())
+		}
+		if s.visiting == nil {
+			s.visiting = make(map[visit]bool)
+		}
+		s.visiting[v] = true
+		defer delete(s.visiting, v)
+		src = src.Elem()
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		dst = dst.Elem()
+	}
+	return copyStruct(s, dst, src, path)
+}
+
+// isScalar reports whether t is a bool, a number or a string, named or not
+func isScalar(t reflect.Type) bool {
+	return t.Kind() == reflect.Bool || t.Kind() == reflect.String || isNumber(t.Kind())
+}
+
+// isScalarPointer reports whether t is a pointer to a scalar, e.g. the *string
+// of a proto3 optional field
+func isScalarPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && isScalar(t.Elem())
+}
+
+// pointerRule resolves the rule between the scalars behind src and dst, or
+// between a scalar and the other side when only one of them is a scalar pointer
+func pointerRule(src, dst reflect.Type) rule {
+	if isScalarPointer(src) {
+		src = src.Elem()
+	}
+	if isScalarPointer(dst) {
+		dst = dst.Elem()
+	}
+	return ruleFor(src, dst)
+}
+
+// copyPointer copies between scalar pointers and the values they point to
+// with the rule of the scalars. A non nil src pointer is read through, a new
+// value is allocated for a dst pointer, or nil is set for a zero value under
+// ZeroPointers. src is never a nil pointer, its presence is kept.
+func copyPointer(s *state, dst, src reflect.Value, path string) (string, error) {
+	present := false
+	if isScalarPointer(src.Type()) {
+		src, present = src.Elem(), true
+	}
+	if !isScalarPointer(dst.Type()) {
+		return copyValue(s, dst, src, path)
+	}
+
+	if !present && src.IsZero() && s.zeroPointerToNil() {
+		dst.Set(reflect.Zero(dst.Type()))
+		return "", nil
+	}
+	out := reflect.New(dst.Type().Elem())
+	name, err := copyValue(s, out.Elem(), src, path)
+	if err != nil {
+		return name, err
+	}
+	dst.Set(out)
+	return name, nil
+}
+
+func isSequence(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
+
+// copySequence copies a slice or array src into a slice or array dst, element
+// by element, a nil slice stays nil. dst is only replaced once every element
+// is copied, or with CollectErrors once every element was tried. MaxLength
+// limits source slices, arrays have a fixed size.
+func copySequence(s *state, dst, src reflect.Value, path string) error {
+	n := src.Len()
+	if max := s.opts.maxLength; max > 0 && n > max && src.Kind() == reflect.Slice {
+		return errors.Wrapf(ErrTooLong, "%d elements, the limit is %d", n, max)
+	}
+
+	var out reflect.Value
+	if src.Kind() == reflect.Slice && src.IsNil() && dst.Kind() == reflect.Slice {
+		out = reflect.Zero(dst.Type())
+	} else if dst.Kind() == reflect.Array {
+		if n > dst.Len() {
+			return errors.Wrapf(ErrTooLong, "%d elements for an array of %d", n, dst.Len())
+		}
+		out = reflect.New(dst.Type()).Elem()
+	} else {
+		out = reflect.MakeSlice(dst.Type(), n, n)
+	}
+
+	r := ruleFor(src.Type().Elem(), dst.Type().Elem())
+	for i := 0; i < n; i++ {
+		if err := copyElement(s, r, out.Index(i), src.Index(i), elementPath{path: path, index: i}); err != nil {
+			if err = s.fail(err); err != nil {
+				return err
 			}
+		}
+	}
 
-		// float64 or float32 to DoubleValue or FloatValue
-		case reflect.Float64, reflect.Float32:
-			value := fieldValue.Float()
-			if dstFieldValue.Kind() == doubleValue {
-				spbDouble := &wrapperspb.DoubleValue{
-					Value: value,
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbDouble))
-			} else if dstFieldValue.Kind() == floatValue {
-				spbFloat := &wrapperspb.FloatValue{
-					Value: float32(value),
-				}
-				dstFieldValue.Set(reflect.ValueOf(spbFloat))
-			} else {
-				dstFieldValue.Set(reflect.ValueOf(value))
+	dst.Set(out)
+	return nil
+}
+
+// cloneSlice sets dst to a copy of the slice src, whose elements hold no
+// pointers, a nil slice stays nil
+func cloneSlice(dst, src reflect.Value) {
+	if src.IsNil() {
+		dst.Set(reflect.Zero(dst.Type()))
+		return
+	}
+	out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	reflect.Copy(out, src)
+	dst.Set(out)
+}
+
+// copyMap copies a map src into a map dst, converting keys and values. Keys are
+// visited in a stable order so the first failure is always the same one.
+func copyMap(s *state, dst, src reflect.Value, path string) error {
+	n := src.Len()
+	if max := s.opts.maxLength; max > 0 && n > max {
+		return errors.Wrapf(ErrTooLong, "%d entries, the limit is %d", n, max)
+	}
+
+	keys := src.MapKeys()
+	names := make([]string, n)
+	for i, key := range keys {
+		names[i] = fmt.Sprint(key)
+	}
+	sort.Sort(keyOrder{keys: keys, names: names})
+
+	dstType := dst.Type()
+	out := reflect.MakeMapWithSize(dstType, n)
+	keyRule := ruleFor(src.Type().Key(), dstType.Key())
+	valueRule := ruleFor(src.Type().Elem(), dstType.Elem())
+	for i, srcKey := range keys {
+		at := elementPath{path: path, key: &names[i]}
+
+		key := reflect.New(dstType.Key()).Elem()
+		err := copyKey(s, keyRule, key, srcKey, at)
+		if err == nil && out.MapIndex(key).IsValid() {
+			// two source keys convert to the same one, e.g. "1" and "01"
+			err = newCopyError(at.String(), key, srcKey, errors.Wrapf(ErrDuplicateKey, "%v is the key of another entry", key))
+		}
+		if err == nil {
+			value := reflect.New(dstType.Elem()).Elem()
+			if err = copyElement(s, valueRule, value, src.MapIndex(srcKey), at); err == nil {
+				out.SetMapIndex(key, value)
+				continue
 			}
+		}
+		if err = s.fail(err); err != nil {
+			return err
+		}
+	}
 
-		// timestamp to timestamppb
-		case timestamp:
-			timeValue := fieldValue.Int()
-			newTime := timestamppb.New(time.UnixMilli(timeValue))
-			dstFieldValue.Set(reflect.ValueOf(newTime))
+	dst.Set(out)
+	return nil
+}
 
-		// don't have one of these types, should continue
-		default:
-			continue
+// keyOrder sorts map keys by their printed form
+type keyOrder struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (o keyOrder) Len() int           { return len(o.keys) }
+func (o keyOrder) Less(i, j int) bool { return o.names[i] < o.names[j] }
+func (o keyOrder) Swap(i, j int) {
+	o.keys[i], o.keys[j] = o.keys[j], o.keys[i]
+	o.names[i], o.names[j] = o.names[j], o.names[i]
+}
+
+// isIntegerKeyPair reports whether src and dst are an integer and a string
+// kind, map keys of such types convert with convertIntegerKey
+func isIntegerKeyPair(src, dst reflect.Type) bool {
+	return isInteger(src.Kind()) && dst.Kind() == reflect.String || src.Kind() == reflect.String && isInteger(dst.Kind())
+}
+
+// convertIntegerKey converts between integer and string map keys in base 10
+func convertIntegerKey(dst, src reflect.Value) error {
+	switch {
+	case isInteger(src.Kind()) && dst.Kind() == reflect.String:
+		if isUnsigned(src.Kind()) {
+			dst.SetString(strconv.FormatUint(src.Uint(), 10))
+		} else {
+			dst.SetString(strconv.FormatInt(src.Int(), 10))
+		}
+	case src.Kind() == reflect.String && isUnsigned(dst.Kind()):
+		n, err := strconv.ParseUint(src.String(), 10, dst.Type().Bits())
+		if err != nil {
+			return err
 		}
+		dst.SetUint(n)
+	case src.Kind() == reflect.String && isInteger(dst.Kind()):
+		n, err := strconv.ParseInt(src.String(), 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	default:
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// setNilMap applies the NilMaps policy to dst for a nil source map and
+// reports whether dst was written
+func setNilMap(s *state, dst reflect.Value) bool {
+	if dst.Kind() != reflect.Map {
+		return false
+	}
+	switch s.opts.nilMaps {
+	case NilMapToNil:
+		dst.Set(reflect.Zero(dst.Type()))
+	case NilMapToEmpty:
+		dst.Set(reflect.MakeMap(dst.Type()))
+	default:
+		return false
+	}
+	return true
+}
 
+// convertible reports whether src can be converted to dst with a plain Go conversion.
+// Only conversions inside the same family of basic kinds are allowed, so that
+// int -> string or []byte -> string are never picked up by accident. Numbers
+// are range checked, see convertNumber.
+func convertible(src, dst reflect.Type) bool {
+	if !src.ConvertibleTo(dst) {
+		return false
 	}
+	switch {
+	case isNumber(src.Kind()) && isNumber(dst.Kind()):
+		return true
+	case src.Kind() == reflect.String && dst.Kind() == reflect.String:
+		return true
+	case src.Kind() == reflect.Bool && dst.Kind() == reflect.Bool:
+		return true
+	}
+	return false
+}
 
+// convertNumber converts between two number kinds. A value dst can't hold, or
+// a float with a fractional part into an integer, fails with ErrOverflow, a
+// float may lose precision.
+func convertNumber(dst, src reflect.Value) error {
+	fits := true
+	switch k := dst.Kind(); {
+	case isUnsigned(k) && isUnsigned(src.Kind()):
+		fits = !dst.OverflowUint(src.Uint())
+	case isUnsigned(k) && isInteger(src.Kind()):
+		fits = src.Int() >= 0 && !dst.OverflowUint(uint64(src.Int()))
+	case isUnsigned(k):
+		f := src.Float()
+		fits = f == math.Trunc(f) && f >= 0 && f < math.Exp2(64) && !dst.OverflowUint(uint64(f))
+	case isInteger(k) && isUnsigned(src.Kind()):
+		fits = src.Uint() <= math.MaxInt64 && !dst.OverflowInt(int64(src.Uint()))
+	case isInteger(k) && isInteger(src.Kind()):
+		fits = !dst.OverflowInt(src.Int())
+	case isInteger(k):
+		f := src.Float()
+		fits = f == math.Trunc(f) && f >= -math.Exp2(63) && f < math.Exp2(63) && !dst.OverflowInt(int64(f))
+	case src.Kind() == reflect.Float32 || src.Kind() == reflect.Float64:
+		fits = !dst.OverflowFloat(src.Float())
+	}
+	if !fits {
+		return errors.Wrapf(ErrOverflow, "%v does not fit %v", src, dst.Type())
+	}
+	dst.Set(src.Convert(dst.Type()))
 	return nil
 }
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return isUnsigned(k)
+}
+
+func isUnsigned(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -1,12 +1,14 @@
 package copier
 
 import (
+	"errors"
 	"fmt"
 	v1 "github.com/alexwangfufa/struct-copy/example/api/material-group/v1"
 	"github.com/alexwangfufa/struct-copy/example/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -76,3 +78,1134 @@ func Test_Copier(t *testing.T) {
 	}
 
 }
+
+func Test_CopyDispatchesOnType(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	now := time.Date(2022, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	req := &v1.SaveMaterialGroupRequest{}
+	if err := Copy(req, &domain.MaterialGroup{Id: &objectID, UserId: "u1", Ut64: 1 << 63, UpdateTime: now}); err != nil {
+		t.Fatalf("copy to request: %v", err)
+	}
+	if req.Id.GetValue() != objectID.Hex() || req.UserId.GetValue() != "u1" || req.Ut64.GetValue() != 1<<63 {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if !req.UpdateTime.AsTime().Equal(now) {
+		t.Fatalf("update time = %v, want %v", req.UpdateTime.AsTime(), now)
+	}
+
+	group := &domain.MaterialGroup{}
+	if err := Copy(group, req); err != nil {
+		t.Fatalf("copy to domain: %v", err)
+	}
+	if group.Id == nil || *group.Id != objectID || group.UserId != "u1" || group.Ut64 != 1<<63 || !group.UpdateTime.Equal(now) {
+		t.Fatalf("unexpected domain: %+v", group)
+	}
+}
+
+func Test_CopyNil(t *testing.T) {
+	mapper := MustCompile(reflect.TypeOf(v1.MaterialGroupModel{}), reflect.TypeOf(domain.MaterialGroup{}))
+	testCases := []struct {
+		Name string
+		Copy func() error
+		Want string
+	}{
+		{"nilSource", func() error { return Copy(&v1.MaterialGroupModel{}, (*domain.MaterialGroup)(nil)) }, "src should be a non nil struct pointer"},
+		{"nilDest", func() error { return Copy((*v1.MaterialGroupModel)(nil), &domain.MaterialGroup{}) }, "dest should be a non nil struct pointer"},
+		{"untypedSource", func() error { return Copy(&v1.MaterialGroupModel{}, nil) }, "src type should be a struct pointer"},
+		{"untypedDest", func() error { return Copy(nil, &domain.MaterialGroup{}) }, "dest type should be a struct pointer"},
+		{"mapperNilSource", func() error { return mapper.Copy(&v1.MaterialGroupModel{}, (*domain.MaterialGroup)(nil)) }, "src should be a non nil struct pointer"},
+		{"mapperNilDest", func() error { return mapper.Copy((*v1.MaterialGroupModel)(nil), &domain.MaterialGroup{}) }, "dest should be a non nil struct pointer"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			if err := testCase.Copy(); err == nil || err.Error() != testCase.Want {
+				t.Fatalf("expected %q, got %v", testCase.Want, err)
+			}
+		})
+	}
+}
+
+type wideNumbers struct {
+	N int64
+	U uint64
+	F float64
+}
+
+type narrowNumbers struct {
+	N int8
+	U int32
+	F float32
+}
+
+func Test_CopyNumbers(t *testing.T) {
+	narrow := &narrowNumbers{}
+	if err := Copy(narrow, &wideNumbers{N: -128, U: 1<<31 - 1, F: 0.5}); err != nil {
+		t.Fatalf("copy numbers in range: %v", err)
+	}
+	if *narrow != (narrowNumbers{N: -128, U: 1<<31 - 1, F: 0.5}) {
+		t.Fatalf("unexpected numbers: %+v", narrow)
+	}
+
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+		path string
+	}{
+		{"int overflow", &narrowNumbers{}, &wideNumbers{N: 300}, "N"},
+		{"uint overflow", &narrowNumbers{}, &wideNumbers{U: 1 << 31}, "U"},
+		{"float overflow", &narrowNumbers{}, &wideNumbers{F: 1e39}, "F"},
+		{"negative into uint", &struct{ N uint8 }{}, &wideNumbers{N: -1}, "N"},
+		{"fraction into int", &struct{ F int64 }{}, &wideNumbers{F: 1.5}, "F"},
+		{"float over int64", &struct{ F int64 }{}, &wideNumbers{F: 1e19}, "F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != tt.path || !errors.Is(err, ErrOverflow) {
+				t.Fatalf("expected ErrOverflow at %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
+
+type money struct {
+	Cents int64
+}
+
+type priceModel struct {
+	Price string
+}
+
+type priceEntity struct {
+	Price money
+}
+
+func Test_RegisterConverter(t *testing.T) {
+	RegisterConverter(reflect.TypeOf(money{}), reflect.TypeOf(""), func(src interface{}) (interface{}, error) {
+		m := src.(money)
+		return fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100), nil
+	})
+	RegisterConverter(reflect.TypeOf(""), reflect.TypeOf(money{}), func(src interface{}) (interface{}, error) {
+		var whole, cents int64
+		if _, err := fmt.Sscanf(src.(string), "%d.%d", &whole, &cents); err != nil {
+			return nil, err
+		}
+		return money{Cents: whole*100 + cents}, nil
+	})
+
+	model := &priceModel{}
+	if err := Copy(model, &priceEntity{Price: money{Cents: 1205}}); err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	if model.Price != "12.05" {
+		t.Fatalf("price = %q, want 12.05", model.Price)
+	}
+
+	entity := &priceEntity{}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	if entity.Price.Cents != 1205 {
+		t.Fatalf("cents = %d, want 1205", entity.Price.Cents)
+	}
+
+	if err := Copy(entity, &priceModel{Price: "free"}); err == nil {
+		t.Fatalf("expected converter error")
+	}
+
+	// a registered converter doesn't apply to named types of its base type,
+	// nor does a built-in one to types with rules of their own
+	type label string
+	type period struct{ At time.Duration }
+	if err := Copy(entity, &struct{ Price label }{Price: "1.00"}, RequireSupported()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported for a named string, got %v", err)
+	}
+	at := &struct{ At time.Time }{}
+	if err := Copy(at, &period{At: time.Second}, UnixTime(time.Second), RequireSupported()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported for a duration into a time, got %v %v", at, err)
+	}
+}
+
+type explosive struct {
+	Fuse int
+}
+
+type explosiveHolder struct {
+	Bomb explosive
+}
+
+type explosiveTarget struct {
+	Bomb string
+}
+
+func Test_CopyError(t *testing.T) {
+	err := Copy(&domain.MaterialGroup{}, &v1.MaterialGroupModel{Id: "not-a-hex-id"})
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) {
+		t.Fatalf("expected *CopyError, got %v", err)
+	}
+	if copyErr.Path != "Id" || copyErr.Value != "not-a-hex-id" || copyErr.SrcType != reflect.TypeOf("") || copyErr.DstType != objectIDPtr {
+		t.Fatalf("unexpected error: %+v", copyErr)
+	}
+	if copyErr.Err != primitive.ErrInvalidHex {
+		t.Fatalf("cause = %v, want %v", copyErr.Err, primitive.ErrInvalidHex)
+	}
+
+	RegisterConverter(reflect.TypeOf(explosive{}), reflect.TypeOf(""), func(src interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	err = Copy(&explosiveTarget{}, &explosiveHolder{})
+	if !errors.As(err, &copyErr) || copyErr.Path != "Bomb" {
+		t.Fatalf("expected recovered panic for Bomb, got %v", err)
+	}
+
+	// a missing side is left out of the message, the path of ErrNoDestination
+	// is the source field
+	err = Copy(&struct{}{}, &struct{ A string }{}, RequireDestination())
+	if !errors.As(err, &copyErr) || copyErr.Path != "A" || err.Error() != "copier: A (from string): no destination field" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = Copy(&struct{ A string }{}, &struct{}{}, RequireSource())
+	if err == nil || err.Error() != "copier: A (to string): no source field" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type idsModel struct {
+	Id       string
+	OwnerId  string
+	ParentId string
+	Name     string
+}
+
+type idsEntity struct {
+	Id       *primitive.ObjectID
+	OwnerId  primitive.ObjectID
+	ParentId *primitive.ObjectID
+	Name     string
+}
+
+func Test_CollectErrors(t *testing.T) {
+	src := &idsModel{Id: "bad-1", OwnerId: "bad-2", ParentId: "bad-3", Name: "kept"}
+
+	err := Copy(&idsEntity{}, src)
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Id" {
+		t.Fatalf("expected first failure on Id, got %v", err)
+	}
+
+	dst := &idsEntity{}
+	err = Copy(dst, src, CollectErrors())
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	if len(errs) != 3 || errs[0].Path != "Id" || errs[1].Path != "OwnerId" || errs[2].Path != "ParentId" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if dst.Name != "kept" {
+		t.Fatalf("name = %q, want kept", dst.Name)
+	}
+
+	// the methods match the field errors without Unwrap() []error, as before Go 1.20
+	if !errs.Is(primitive.ErrInvalidHex) || errs.Is(ErrNoSource) {
+		t.Fatalf("Errors.Is didn't match the field errors: %v", errs)
+	}
+	if copyErr = nil; !errs.As(&copyErr) || copyErr != errs[0] {
+		t.Fatalf("Errors.As = %v, want the first field error", copyErr)
+	}
+}
+
+func Test_Strict(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	src := &domain.MaterialGroup{Id: &objectID, Name: "strict"}
+
+	if err := Copy(&v1.MaterialGroupModel{}, src); err != nil {
+		t.Fatalf("non strict copy: %v", err)
+	}
+
+	err := Copy(&v1.MaterialGroupModel{}, src, RequireDestination())
+	if !errors.Is(err, ErrNoDestination) {
+		t.Fatalf("expected ErrNoDestination, got %v", err)
+	}
+
+	except := []string{"Ut64", "OrgId", "UserId", "Ut32", "Scope", "It", "IsValid", "StoryPoint", "Point", "CreateTime", "UpdateTime"}
+	if err := Copy(&v1.MaterialGroupModel{}, src, Strict(), RequireDestination(except...)); err != nil {
+		t.Fatalf("strict copy with allowlist: %v", err)
+	}
+
+	err = Copy(&priceModel{}, &idsModel{}, RequireSource(), CollectErrors())
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != "Price" || errs[0].Err != ErrNoSource {
+		t.Fatalf("expected missing source for Price, got %v", err)
+	}
+
+	err = Copy(&explosiveTarget{}, &struct{ Bomb []int }{Bomb: []int{1}}, RequireSupported())
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	if err := Copy(&explosiveTarget{}, &struct{ Bomb []int }{Bomb: []int{1}}, RequireSupported("Bomb")); err != nil {
+		t.Fatalf("allowlisted unsupported field: %v", err)
+	}
+}
+
+func Test_CopyWithReport(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	src := &domain.MaterialGroup{Id: &objectID, Name: "report", Order: 3, It: 7}
+
+	for _, dryRun := range []bool{false, true} {
+		var opts []Option
+		if dryRun {
+			opts = append(opts, DryRun())
+		}
+		dst := &v1.SaveMaterialGroupRequest{}
+		report, err := CopyWithReport(dst, src, opts...)
+		if err != nil {
+			t.Fatalf("copy with report: %v", err)
+		}
+		if report.DryRun != dryRun {
+			t.Fatalf("dry run = %v, want %v", report.DryRun, dryRun)
+		}
+
+		name, _ := report.Field("Name")
+		id, _ := report.Field("Id")
+		orgID, _ := report.Field("OrgId")
+		if name.Action != Copied || id.Action != Converted || id.Converter != "*primitive.ObjectID -> *wrapperspb.StringValue" {
+			t.Fatalf("unexpected entries: %+v %+v", name, id)
+		}
+		if orgID.Action != Copied {
+			t.Fatalf("unexpected OrgId entry: %+v", orgID)
+		}
+		if len(report.Fields) != 15 {
+			t.Fatalf("got %d entries, want one per destination field", len(report.Fields))
+		}
+
+		if written := dst.Name == "report"; written == dryRun {
+			t.Fatalf("dry run %v wrote dst: %v", dryRun, dst)
+		}
+	}
+
+	report, _ := CopyWithReport(&domain.MaterialGroup{}, &v1.MaterialGroupModel{})
+	if f, _ := report.Field("Ut64"); f.Action != Skipped || f.Reason != ReasonNoSource {
+		t.Fatalf("unexpected Ut64 entry: %+v", f)
+	}
+	report, _ = CopyWithReport(&domain.MaterialGroup{}, &v1.SaveMaterialGroupRequest{})
+	if f, _ := report.Field("Id"); f.Action != Skipped || f.Reason != ReasonNilPointer {
+		t.Fatalf("unexpected Id entry: %+v", f)
+	}
+
+	// a field promoted through a nil embedded pointer is a nil pointer too
+	type inner struct{ Name string }
+	promoted := &struct {
+		Name string
+		Age  int
+	}{Name: "keep"}
+	report, err := CopyWithReport(promoted, &struct {
+		*inner
+		Age int
+	}{Age: 3}, CollectErrors())
+	if err != nil || promoted.Name != "keep" || promoted.Age != 3 {
+		t.Fatalf("unexpected promoted copy: %+v %v", promoted, err)
+	}
+	if f, _ := report.Field("Name"); f.Action != Skipped || f.Reason != ReasonNilPointer {
+		t.Fatalf("unexpected Name entry: %+v", f)
+	}
+}
+
+type taggedEntity struct {
+	OrgId      string `copier:"OrganizationId"`
+	Secret     string `copier:"-"`
+	Owner      string `copier:"from=UserId|OwnerId"`
+	CreateTime int64  `copier:"from=-"`
+	Password   string `copier:"to=-"`
+}
+
+type taggedModel struct {
+	OrganizationId string
+	Secret         string
+	OwnerId        string
+	Owner          string
+	CreateTime     int64
+	Password       string
+}
+
+func Test_CopierTag(t *testing.T) {
+	entity := &taggedEntity{}
+	err := Copy(entity, &taggedModel{OrganizationId: "org", Secret: "s", OwnerId: "owner", CreateTime: 1, Password: "p"})
+	if err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	want := taggedEntity{OrgId: "org", Owner: "owner", Password: "p"}
+	if *entity != want {
+		t.Fatalf("entity = %+v, want %+v", *entity, want)
+	}
+
+	model := &taggedModel{}
+	err = Copy(model, &taggedEntity{OrgId: "org", Secret: "s", Owner: "owner", CreateTime: 1, Password: "p"})
+	if err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	wantModel := taggedModel{OrganizationId: "org", Owner: "owner", CreateTime: 1}
+	if *model != wantModel {
+		t.Fatalf("model = %+v, want %+v", *model, wantModel)
+	}
+
+	report, _ := CopyWithReport(&taggedEntity{}, &taggedModel{})
+	if f, _ := report.Field("Secret"); f.Action != Skipped || f.Reason != ReasonIgnored {
+		t.Fatalf("unexpected Secret entry: %+v", f)
+	}
+
+	// the source of an ignored field is skipped on purpose, not unused
+	ignored := &struct {
+		A string `copier:"-"`
+		B string `copier:"from=-"`
+	}{}
+	if err := Copy(ignored, &struct{ A, B string }{A: "a", B: "b"}, Strict()); err != nil || ignored.A != "" || ignored.B != "" {
+		t.Fatalf("unexpected strict copy into ignored fields: %+v %v", ignored, err)
+	}
+}
+
+type bsonEntity struct {
+	Key   *primitive.ObjectID `bson:"_id,omitempty"`
+	Title string              `bson:"name"`
+	Rank  int64               `bson:"order"`
+}
+
+type snakeModel struct {
+	Org_Id   string
+	USERID   string
+	Position int64 `protobuf:"varint,4,opt,name=position,proto3"`
+}
+
+func Test_MatchStrategies(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+
+	model := &v1.MaterialGroupModel{}
+	if err := Copy(model, &bsonEntity{Key: &objectID, Title: "by tag", Rank: 4}); err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	if model.Id != objectID.Hex() || model.Name != "by tag" || model.Order != 4 {
+		t.Fatalf("unexpected model: %v", model)
+	}
+
+	entity := &bsonEntity{}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	if entity.Key == nil || *entity.Key != objectID || entity.Title != "by tag" {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	group := &domain.MaterialGroup{}
+	src := &snakeModel{Org_Id: "org", USERID: "user", Position: 9}
+	if err := Copy(group, src); err != nil {
+		t.Fatalf("copy with defaults: %v", err)
+	}
+	if group.OrgId != "" || group.UserId != "" {
+		t.Fatalf("default strategies matched loosely: %+v", group)
+	}
+	if err := Copy(group, src, MatchBy(MatchNormalized, MatchNameFold)); err != nil {
+		t.Fatalf("copy with loose strategies: %v", err)
+	}
+	if group.OrgId != "org" || group.UserId != "user" {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+
+	model = &v1.MaterialGroupModel{}
+	if err := Copy(model, src, MatchBy(MatchProtoNumber)); err != nil {
+		t.Fatalf("copy by proto number: %v", err)
+	}
+	if model.Order != 9 {
+		t.Fatalf("order = %d, want 9", model.Order)
+	}
+}
+
+type ownerEntity struct {
+	Id   *primitive.ObjectID
+	Name string
+}
+
+type teamEntity struct {
+	Name    string
+	Owner   ownerEntity
+	Manager *ownerEntity
+	Deputy  *ownerEntity
+}
+
+type ownerModel struct {
+	Id   *wrapperspb.StringValue
+	Name string
+}
+
+type teamModel struct {
+	Name    string
+	Owner   *ownerModel
+	Manager ownerModel
+	Deputy  *ownerModel
+}
+
+func Test_CopyNested(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+
+	model := &teamModel{}
+	err := Copy(model, &teamEntity{Name: "team", Owner: ownerEntity{Id: &objectID, Name: "owner"}, Manager: &ownerEntity{Name: "manager"}})
+	if err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	if model.Owner == nil || model.Owner.Id.GetValue() != objectID.Hex() || model.Owner.Name != "owner" {
+		t.Fatalf("unexpected owner: %+v", model.Owner)
+	}
+	if model.Manager.Name != "manager" || model.Deputy != nil {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+
+	entity := &teamEntity{}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	if entity.Owner.Id == nil || *entity.Owner.Id != objectID || entity.Manager == nil || entity.Manager.Name != "manager" {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	err = Copy(&teamEntity{}, &teamModel{Owner: &ownerModel{Id: wrapperspb.String("bad")}})
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Owner.Id" {
+		t.Fatalf("expected error on Owner.Id, got %v", err)
+	}
+}
+
+type nodeEntity struct {
+	Name string
+	Next *nodeEntity
+}
+
+type nodeModel struct {
+	Name string
+	Next *nodeModel
+}
+
+type pairEntity struct {
+	Left  *nodeEntity
+	Right *nodeEntity
+}
+
+type pairModel struct {
+	Left  *nodeModel
+	Right *nodeModel
+}
+
+func Test_CopyCycle(t *testing.T) {
+	loop := &nodeEntity{Name: "a", Next: &nodeEntity{Name: "b"}}
+	loop.Next.Next = loop
+	err := Copy(&nodeModel{}, loop)
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Next.Next" || !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected cycle on Next.Next, got %v", err)
+	}
+
+	shared := &nodeEntity{Name: "shared"}
+	model := &pairModel{}
+	if err := Copy(model, &pairEntity{Left: shared, Right: shared}); err != nil {
+		t.Fatalf("copy shared pointer: %v", err)
+	}
+	if model.Left.Name != "shared" || model.Right.Name != "shared" {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+}
+
+type groupList struct {
+	Data []domain.MaterialGroup
+}
+
+type groupPtrList struct {
+	Data []*domain.MaterialGroup
+}
+
+type idListEntity struct {
+	Ids    []primitive.ObjectID
+	Tags   [3]string
+	Labels []string
+}
+
+type idListModel struct {
+	Ids    []string
+	Tags   []*wrapperspb.StringValue
+	Labels []*wrapperspb.StringValue
+}
+
+func Test_CopySliceFields(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+
+	list := &v1.MaterialGroupModelList{}
+	if err := Copy(list, &groupList{Data: []domain.MaterialGroup{{Id: &objectID, Name: "a"}, {Name: "b"}}}); err != nil {
+		t.Fatalf("copy values: %v", err)
+	}
+	if len(list.Data) != 2 || list.Data[0].Id != objectID.Hex() || list.Data[1].Name != "b" {
+		t.Fatalf("unexpected list: %v", list.Data)
+	}
+
+	if err := Copy(list, &groupPtrList{Data: []*domain.MaterialGroup{{Name: "c"}, nil}}); err != nil {
+		t.Fatalf("copy pointers: %v", err)
+	}
+	if len(list.Data) != 2 || list.Data[0].Name != "c" || list.Data[1] != nil {
+		t.Fatalf("unexpected list: %v", list.Data)
+	}
+
+	back := &groupPtrList{}
+	if err := Copy(back, list); err != nil {
+		t.Fatalf("copy back: %v", err)
+	}
+	if len(back.Data) != 2 || back.Data[0].Name != "c" {
+		t.Fatalf("unexpected back: %v", back.Data)
+	}
+
+	model := &idListModel{}
+	entity := &idListEntity{Ids: []primitive.ObjectID{objectID}, Tags: [3]string{"x", "y"}, Labels: []string{"l"}}
+	if err := Copy(model, entity); err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	if len(model.Ids) != 1 || model.Ids[0] != objectID.Hex() || len(model.Tags) != 3 || model.Tags[1].GetValue() != "y" || model.Labels[0].GetValue() != "l" {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+
+	entity = &idListEntity{}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	if len(entity.Ids) != 1 || entity.Ids[0] != objectID || entity.Tags[1] != "y" {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	err := Copy(&idListEntity{}, &idListModel{Ids: []string{objectID.Hex(), "bad"}})
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Ids[1]" {
+		t.Fatalf("expected error on Ids[1], got %v", err)
+	}
+
+	err = Copy(&idListEntity{}, &idListModel{Ids: []string{objectID.Hex(), objectID.Hex()}}, MaxLength(1))
+	if !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+	err = Copy(&idListEntity{}, &idListModel{Tags: make([]*wrapperspb.StringValue, 4)})
+	if !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong for the array, got %v", err)
+	}
+}
+
+type settingsEntity struct {
+	Enabled bool
+	Limit   int64
+}
+
+type settingsModel struct {
+	Enabled *wrapperspb.BoolValue
+	Limit   int64
+}
+
+type mapEntity struct {
+	Owners   map[string]primitive.ObjectID
+	Settings map[primitive.ObjectID]settingsEntity
+	Counts   map[int32]int64
+	Extra    map[string]string
+}
+
+type mapModel struct {
+	Owners   map[string]string
+	Settings map[string]*settingsModel
+	Counts   map[string]int64
+	Extra    map[string]string
+}
+
+func Test_CopyMap(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+
+	model := &mapModel{}
+	err := Copy(model, &mapEntity{
+		Owners:   map[string]primitive.ObjectID{"a": objectID},
+		Settings: map[primitive.ObjectID]settingsEntity{objectID: {Enabled: true, Limit: 3}},
+		Counts:   map[int32]int64{7: 70},
+	})
+	if err != nil {
+		t.Fatalf("copy to model: %v", err)
+	}
+	if model.Owners["a"] != objectID.Hex() || model.Counts["7"] != 70 {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+	if s := model.Settings[objectID.Hex()]; s == nil || !s.Enabled.GetValue() || s.Limit != 3 {
+		t.Fatalf("unexpected settings: %+v", model.Settings)
+	}
+	if model.Extra != nil {
+		t.Fatalf("nil map became %v", model.Extra)
+	}
+
+	entity := &mapEntity{}
+	if err := Copy(entity, model, NilMaps(NilMapToEmpty)); err != nil {
+		t.Fatalf("copy to entity: %v", err)
+	}
+	if entity.Owners["a"] != objectID || !entity.Settings[objectID].Enabled || entity.Counts[7] != 70 {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+	if entity.Extra == nil || len(entity.Extra) != 0 {
+		t.Fatalf("nil map should become empty, got %v", entity.Extra)
+	}
+
+	entity.Extra = map[string]string{"k": "v"}
+	if err := Copy(entity, &mapModel{}, NilMaps(NilMapToNil)); err != nil || entity.Extra != nil {
+		t.Fatalf("nil map should become nil, got %v %v", entity.Extra, err)
+	}
+
+	err = Copy(&mapEntity{}, &mapModel{Counts: map[string]int64{"1": 1, "x": 2}})
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Counts[x]" {
+		t.Fatalf("expected error on Counts[x], got %v", err)
+	}
+
+	// "1" converts to the key of "01", which sorts first, it is reported and
+	// doesn't overwrite it
+	entity = &mapEntity{}
+	err = Copy(entity, &mapModel{Counts: map[string]int64{"1": 1, "01": 2}}, CollectErrors())
+	if !errors.As(err, &copyErr) || copyErr.Path != "Counts[1]" || !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("expected ErrDuplicateKey on Counts[1], got %v", err)
+	}
+	if len(entity.Counts) != 1 || entity.Counts[1] != 2 {
+		t.Fatalf("unexpected counts: %v", entity.Counts)
+	}
+
+	// an unsupported field of a value keeps its own path
+	err = Copy(&struct{ Items map[string]explosiveTarget }{}, &struct{ Items map[string]bombEntity }{
+		Items: map[string]bombEntity{"a": {Bomb: []int{1}}},
+	}, RequireSupported())
+	if !errors.As(err, &copyErr) || copyErr.Path != "Items[a].Bomb" || !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported on Items[a].Bomb, got %v", err)
+	}
+}
+
+type sharedEntity struct {
+	Id     primitive.ObjectID
+	Tags   []string
+	Data   []byte
+	Grid   [][]int
+	Counts map[string]int
+}
+
+type bombEntity struct {
+	Bomb []int
+}
+
+type bombHolder struct {
+	Items []bombEntity
+}
+
+type bombTarget struct {
+	Items []explosiveTarget
+}
+
+func Test_CopyIdenticalContainers(t *testing.T) {
+	src := &sharedEntity{
+		Tags:   []string{"a"},
+		Grid:   [][]int{{1}, nil},
+		Counts: map[string]int{"a": 1},
+	}
+	dst := &sharedEntity{}
+	if err := Copy(dst, src); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	// dst owns its slices and maps, a nil inner slice stays nil
+	src.Tags[0], src.Grid[0][0], src.Counts["a"] = "changed", 2, 2
+	if dst.Tags[0] != "a" || dst.Grid[0][0] != 1 || dst.Counts["a"] != 1 {
+		t.Fatalf("dst shares memory with src: %+v", dst)
+	}
+	if len(dst.Grid) != 2 || dst.Grid[1] != nil {
+		t.Fatalf("unexpected grid: %v", dst.Grid)
+	}
+
+	// arrays are assigned and slices of flat elements cloned, neither counts
+	// against MaxLength or reports a conversion
+	src.Id[0], src.Data = 1, []byte("some bytes")
+	report, err := CopyWithReport(dst, src, MaxLength(5))
+	if err != nil || dst.Id != src.Id || string(dst.Data) != "some bytes" {
+		t.Fatalf("unexpected copy under MaxLength: %+v %v", dst, err)
+	}
+	if src.Data[0] = 'S'; dst.Data[0] != 's' {
+		t.Fatalf("dst shares Data with src")
+	}
+	for _, name := range []string{"Id", "Data"} {
+		if f, _ := report.Field(name); f.Action != Copied {
+			t.Fatalf("unexpected %s entry: %+v", name, f)
+		}
+	}
+
+	// an unsupported field of an element keeps its own path
+	err = Copy(&bombTarget{}, &bombHolder{Items: []bombEntity{{Bomb: []int{1}}}}, RequireSupported())
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Path != "Items[0].Bomb" || !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported on Items[0].Bomb, got %v", err)
+	}
+	items := &bombTarget{}
+	if err := Copy(items, &bombHolder{Items: []bombEntity{{Bomb: []int{1}}}}); err != nil || len(items.Items) != 1 {
+		t.Fatalf("unsupported element field should be skipped, got %+v %v", items, err)
+	}
+}
+
+func Test_CopySlice(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	groups := []domain.MaterialGroup{{Id: &objectID, Name: "a", Order: 1}, {Name: "b", Order: 2}}
+
+	var models []*v1.MaterialGroupModel
+	if err := CopySlice(&models, groups); err != nil {
+		t.Fatalf("copy slice: %v", err)
+	}
+	if len(models) != 2 || models[0].Id != objectID.Hex() || models[1].Name != "b" || models[1].Order != 2 {
+		t.Fatalf("unexpected models: %v", models)
+	}
+
+	var back []domain.MaterialGroup
+	if err := CopySlice(&back, &models); err != nil {
+		t.Fatalf("copy slice back: %v", err)
+	}
+	if len(back) != 2 || *back[0].Id != objectID || back[1].Name != "b" {
+		t.Fatalf("unexpected groups: %+v", back)
+	}
+
+	models[0].Id, models[1].Id = "bad", "worse"
+	err := CopySlice(&back, models, CollectErrors())
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Path != "[0].Id" || errs[1].Path != "[1].Id" {
+		t.Fatalf("expected failures by index, got %v", err)
+	}
+
+	if err := CopySlice(back, models); err == nil {
+		t.Fatalf("expected error for a non pointer destination")
+	}
+}
+
+func Test_Generics(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	group := domain.MaterialGroup{Id: &objectID, Name: "typed"}
+
+	model, err := To[*v1.MaterialGroupModel](group)
+	if err != nil || model == nil || model.Id != objectID.Hex() || model.Name != "typed" {
+		t.Fatalf("unexpected To pointer result: %v %v", model, err)
+	}
+
+	value, err := To[domain.MaterialGroup](model)
+	if err != nil || value.Id == nil || *value.Id != objectID {
+		t.Fatalf("unexpected To value result: %+v %v", value, err)
+	}
+
+	req := v1.SaveMaterialGroupRequest{}
+	if err := Into(&req, &group); err != nil || req.Name != "typed" {
+		t.Fatalf("unexpected Into result: %v %v", req.Name, err)
+	}
+
+	models, err := Slice[*v1.MaterialGroupModel]([]domain.MaterialGroup{group, group})
+	if err != nil || len(models) != 2 || models[1].Name != "typed" {
+		t.Fatalf("unexpected Slice result: %v %v", models, err)
+	}
+}
+
+type scalarEntity struct {
+	F64   float64
+	F32   float32
+	I64   int64
+	U64   uint64
+	I32   int32
+	U32   uint32
+	B     bool
+	S     string
+	Bytes []byte
+	Name  *string
+	Count *uint32
+	Note  string    `copier:"zero=keep"`
+	Seen  time.Time `copier:"zero=keep"`
+}
+
+type wrapperModel struct {
+	F64   *wrapperspb.DoubleValue
+	F32   *wrapperspb.FloatValue
+	I64   *wrapperspb.Int64Value
+	U64   *wrapperspb.UInt64Value
+	I32   *wrapperspb.Int32Value
+	U32   *wrapperspb.UInt32Value
+	B     *wrapperspb.BoolValue
+	S     *wrapperspb.StringValue
+	Bytes *wrapperspb.BytesValue
+	Name  *wrapperspb.StringValue
+	Count *wrapperspb.UInt32Value
+	Note  *wrapperspb.StringValue `copier:"zero=nil"`
+	Seen  *timestamppb.Timestamp
+}
+
+func Test_CopyWrappers(t *testing.T) {
+	name, count := "n", uint32(0)
+	entity := &scalarEntity{F64: 1.5, F32: 2.5, I64: -3, U64: 1 << 63, I32: -4, U32: 1 << 31, B: true, S: "s",
+		Bytes: []byte("raw"), Name: &name, Count: &count, Note: "note"}
+
+	model := &wrapperModel{}
+	if err := Copy(model, entity); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if model.F64.GetValue() != 1.5 || model.F32.GetValue() != 2.5 || model.I64.GetValue() != -3 || model.U64.GetValue() != 1<<63 ||
+		model.I32.GetValue() != -4 || model.U32.GetValue() != 1<<31 || !model.B.GetValue() || model.S.GetValue() != "s" ||
+		string(model.Bytes.GetValue()) != "raw" || model.Name.GetValue() != "n" || model.Count == nil || model.Note.GetValue() != "note" {
+		t.Fatalf("unexpected model: %v", model)
+	}
+	entity.Bytes[0] = 'R'
+	if string(model.Bytes.GetValue()) != "raw" {
+		t.Fatalf("bytes should be copied, not shared")
+	}
+
+	back := &scalarEntity{}
+	if err := Copy(back, model); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if back.U64 != 1<<63 || back.U32 != 1<<31 || string(back.Bytes) != "raw" || back.Name == nil || *back.Name != "n" ||
+		back.Count == nil || *back.Count != 0 {
+		t.Fatalf("unexpected entity: %+v", back)
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantNil   bool
+		wantNote  bool
+		wantCount bool
+		wantSeen  bool
+	}{
+		// zero values are wrapped by default, the tags override the policy per field
+		{"keep", nil, false, false, true, true},
+		{"to nil", []Option{ZeroWrappers(ZeroWrapperToNil)}, true, false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &wrapperModel{}
+			if err := Copy(model, &scalarEntity{Bytes: []byte{}, Count: &count}, tt.opts...); err != nil {
+				t.Fatalf("copy zero entity: %v", err)
+			}
+			if (model.I64 == nil) != tt.wantNil || (model.S == nil) != tt.wantNil || (model.Bytes == nil) != tt.wantNil {
+				t.Fatalf("unexpected zero wrappers: %v", model)
+			}
+			if (model.Note != nil) != tt.wantNote || (model.Count != nil) != tt.wantCount || (model.Seen != nil) != tt.wantSeen {
+				t.Fatalf("unexpected tagged wrappers: %v", model)
+			}
+		})
+	}
+}
+
+type optionalEntity struct {
+	UserId string
+	Order  int64 `copier:"zero=keep"`
+	Count  int64
+	Scope  *domain.MaterialGroupScope
+	Score  *int64
+	Tags   []string
+}
+
+type optionalModel struct {
+	UserId *string
+	Order  *int64
+	Count  *int32
+	Scope  string
+	Score  *wrapperspb.Int64Value
+	Tags   []*string
+}
+
+func Test_CopyScalarPointers(t *testing.T) {
+	user, order, count, tag := "u", int64(0), int32(7), "a"
+	model := &optionalModel{UserId: &user, Order: &order, Count: &count, Scope: "group", Score: wrapperspb.Int64(3), Tags: []*string{&tag, nil}}
+
+	entity := &optionalEntity{Order: 9}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if entity.UserId != "u" || entity.Order != 0 || entity.Count != 7 || entity.Scope == nil || *entity.Scope != domain.Group ||
+		entity.Score == nil || *entity.Score != 3 || len(entity.Tags) != 2 || entity.Tags[0] != "a" || entity.Tags[1] != "" {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	// a nil pointer is unset and leaves the destination untouched
+	entity = &optionalEntity{UserId: "keep"}
+	if err := Copy(entity, &optionalModel{}); err != nil {
+		t.Fatalf("copy empty model: %v", err)
+	}
+	if entity.UserId != "keep" {
+		t.Fatalf("a nil pointer should be skipped, got %+v", entity)
+	}
+
+	back := &optionalModel{}
+	if err := Copy(back, &optionalEntity{UserId: "u", Count: 7, Scope: &domain.User, Tags: []string{"a"}}); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if back.UserId == nil || *back.UserId != "u" || back.Order == nil || *back.Count != 7 || back.Scope != "user" ||
+		len(back.Tags) != 1 || *back.Tags[0] != "a" {
+		t.Fatalf("unexpected model: %+v", back)
+	}
+	if back.UserId == &entity.UserId || back.Tags[0] == &tag {
+		t.Fatalf("pointers should be allocated, not shared")
+	}
+
+	// zero values point to zero by default, ZeroPointers turns them into nil
+	// except for the field tagged zero=keep
+	back = &optionalModel{}
+	if err := Copy(back, &optionalEntity{}, ZeroPointers(ZeroPointerToNil)); err != nil {
+		t.Fatalf("copy zero entity: %v", err)
+	}
+	if back.UserId != nil || back.Count != nil || back.Order == nil || *back.Order != 0 {
+		t.Fatalf("unexpected zero model: %+v", back)
+	}
+
+	// pointers to different scalars convert what they point to
+	scope := "org"
+	scoped := &struct{ Scope *domain.MaterialGroupScope }{}
+	if err := Copy(scoped, &struct{ Scope *string }{Scope: &scope}); err != nil {
+		t.Fatalf("copy pointer to pointer: %v", err)
+	}
+	if scoped.Scope == nil || *scoped.Scope != domain.Organization {
+		t.Fatalf("unexpected scope: %v", scoped.Scope)
+	}
+}
+
+type objectIDEntity struct {
+	Id       primitive.ObjectID
+	OwnerId  *primitive.ObjectID
+	TagIds   []primitive.ObjectID
+	RefIds   []primitive.ObjectID
+	Names    map[primitive.ObjectID]string
+	RawId    primitive.ObjectID
+	ParentId primitive.ObjectID
+}
+
+type objectIDModel struct {
+	Id       *primitive.ObjectID
+	OwnerId  primitive.ObjectID
+	TagIds   []string
+	RefIds   []*wrapperspb.StringValue
+	Names    map[string]string
+	RawId    []byte
+	ParentId *primitive.ObjectID
+}
+
+type objectIDCreated struct {
+	Id       time.Time
+	OwnerId  *timestamppb.Timestamp
+	ParentId *time.Time
+}
+
+func Test_CopyObjectIDs(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	owner := primitive.NewObjectID()
+	entity := &objectIDEntity{Id: id, OwnerId: &owner, TagIds: []primitive.ObjectID{id, owner}, RefIds: []primitive.ObjectID{owner},
+		Names: map[primitive.ObjectID]string{id: "a"}, RawId: id}
+
+	model := &objectIDModel{}
+	if err := Copy(model, entity); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if model.Id == nil || *model.Id != id || model.OwnerId != owner || len(model.TagIds) != 2 || model.TagIds[1] != owner.Hex() ||
+		model.RefIds[0].GetValue() != owner.Hex() || model.Names[id.Hex()] != "a" || string(model.RawId) != string(id[:]) {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+	if model.ParentId != nil {
+		t.Fatalf("the zero id should become nil, got %v", model.ParentId)
+	}
+
+	back := &objectIDEntity{}
+	if err := Copy(back, model); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if !reflect.DeepEqual(back, entity) {
+		t.Fatalf("unexpected entity: %+v", back)
+	}
+
+	if err := Copy(&objectIDEntity{}, &objectIDModel{RawId: []byte("short")}); err == nil {
+		t.Fatalf("expected error for an id that is not 12 bytes")
+	}
+
+	// the creation time only with ObjectIDTime
+	created := &objectIDCreated{}
+	if err := Copy(created, entity, ObjectIDTime()); err != nil {
+		t.Fatalf("copy creation times: %v", err)
+	}
+	if !created.Id.Equal(id.Timestamp()) || !created.OwnerId.AsTime().Equal(owner.Timestamp()) || created.ParentId != nil {
+		t.Fatalf("unexpected creation times: %+v", created)
+	}
+	if err := Copy(&objectIDCreated{}, entity, RequireSupported()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported without ObjectIDTime, got %v", err)
+	}
+}
+
+type level int32
+
+type enumModel struct {
+	Type   *wrapperspb.StringValue
+	Scope  string
+	Scopes []string
+	Level  *wrapperspb.Int32Value
+}
+
+type enumEntity struct {
+	Type   domain.MaterialGroupType
+	Scope  domain.MaterialGroupScope
+	Scopes []domain.MaterialGroupScope
+	Level  level
+}
+
+func Test_CopyEnums(t *testing.T) {
+	RegisterEnum(domain.Null, domain.Welcome, domain.OrganizationTemplate, domain.SelfTemplate, domain.BroadcastJob)
+	RegisterEnum(domain.Organization, domain.Group, domain.User)
+
+	// named types convert through their base type
+	entity := &enumEntity{}
+	src := &enumModel{Type: wrapperspb.String("welcome"), Scope: "group", Scopes: []string{"org", "user"}, Level: wrapperspb.Int32(3)}
+	if err := Copy(entity, src); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if entity.Type != domain.Welcome || entity.Scopes[1] != domain.User || entity.Level != 3 {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	model := &enumModel{}
+	if err := Copy(model, entity); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if model.Type.GetValue() != "welcome" || model.Level.GetValue() != 3 || len(model.Scopes) != 2 {
+		t.Fatalf("unexpected model: %v", model)
+	}
+
+	tests := []struct {
+		name string
+		src  *enumModel
+		path string
+	}{
+		{"wrapper", &enumModel{Type: wrapperspb.String("farewell")}, "Type"},
+		{"string", &enumModel{Scope: "planet"}, "Scope"},
+		{"element", &enumModel{Scope: "org", Scopes: []string{"org", "planet"}}, "Scopes[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := &enumEntity{Type: domain.SelfTemplate}
+			err := Copy(entity, tt.src)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != tt.path || !errors.Is(err, ErrUnknownEnum) {
+				t.Fatalf("expected ErrUnknownEnum at %s, got %v", tt.path, err)
+			}
+			if entity.Type != domain.SelfTemplate {
+				t.Fatalf("an unknown value should not be written, got %v", entity.Type)
+			}
+		})
+	}
+
+	if err := CheckEnum(domain.MaterialGroupType("farewell")); !errors.Is(err, ErrUnknownEnum) {
+		t.Fatalf("expected ErrUnknownEnum, got %v", err)
+	}
+	if err := CheckEnum("farewell"); err != nil {
+		t.Fatalf("unregistered types are not checked, got %v", err)
+	}
+	if _, err := domain.ParseMaterialGroupType("welcome"); err != nil {
+		t.Fatalf("parse material group type: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package copier
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func init() {
+	// time.Duration <-> durationpb.Duration
+	register(durationType, pbDuration, func(_ *state, dst, src reflect.Value) error {
+		dst.Set(reflect.ValueOf(durationpb.New(time.Duration(src.Int()))))
+		return nil
+	})
+	register(pbDuration, durationType, func(_ *state, dst, src reflect.Value) error {
+		d, err := asDuration(src.Interface().(*durationpb.Duration))
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(d))
+		return nil
+	})
+
+	// time.Duration <-> int64, as counts of DurationUnit or as nanoseconds by default
+	register(durationType, int64Type, func(s *state, dst, src reflect.Value) error {
+		dst.SetInt(fromDuration(time.Duration(src.Int()), s.opts.durationUnit))
+		return nil
+	})
+	register(int64Type, durationType, func(s *state, dst, src reflect.Value) error {
+		d, err := toDuration(src.Int(), s.opts.durationUnit)
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(d))
+		return nil
+	})
+
+	// durationpb.Duration <-> int64, only as counts of DurationUnit
+	register(pbDuration, int64Type, func(s *state, dst, src reflect.Value) error {
+		if s.opts.durationUnit == 0 {
+			return ErrUnsupported
+		}
+		d, err := asDuration(src.Interface().(*durationpb.Duration))
+		if err != nil {
+			return err
+		}
+		dst.SetInt(fromDuration(d, s.opts.durationUnit))
+		return nil
+	})
+	register(int64Type, pbDuration, func(s *state, dst, src reflect.Value) error {
+		if s.opts.durationUnit == 0 {
+			return ErrUnsupported
+		}
+		d, err := toDuration(src.Int(), s.opts.durationUnit)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(durationpb.New(d)))
+		return nil
+	})
+}
+
+// asDuration is d.AsDuration failing with ErrTimeRange for invalid durations
+// and for those a time.Duration can't hold, instead of saturating
+func asDuration(d *durationpb.Duration) (time.Duration, error) {
+	if err := d.CheckValid(); err != nil {
+		return 0, errors.Wrap(ErrTimeRange, err.Error())
+	}
+	out := d.AsDuration()
+	if back := durationpb.New(out); back.Seconds != d.Seconds || back.Nanos != d.Nanos {
+		return 0, errors.Wrapf(ErrTimeRange, "duration (seconds:%d nanos:%d) overflows time.Duration", d.Seconds, d.Nanos)
+	}
+	return out, nil
+}
+
+// fromDuration counts d in unit, 0 means nanoseconds
+func fromDuration(d, unit time.Duration) int64 {
+	if unit == 0 {
+		return int64(d)
+	}
+	return int64(d / unit)
+}
+
+// toDuration reads n as a count of unit, 0 means nanoseconds
+func toDuration(n int64, unit time.Duration) (time.Duration, error) {
+	if unit == 0 {
+		return time.Duration(n), nil
+	}
+	d := time.Duration(n) * unit
+	if d/unit != time.Duration(n) {
+		return 0, errors.Wrapf(ErrTimeRange, "%d x %v overflows time.Duration", n, unit)
+	}
+	return d, nil
+}
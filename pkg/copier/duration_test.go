@@ -0,0 +1,73 @@
+package copier
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type durationEntity struct {
+	Timeout time.Duration
+	TTL     time.Duration
+	Retry   time.Duration
+}
+
+type durationModel struct {
+	Timeout *durationpb.Duration
+	TTL     int64
+	Retry   *durationpb.Duration
+}
+
+func Test_CopyDuration(t *testing.T) {
+	model := &durationModel{}
+	if err := Copy(model, &durationEntity{Timeout: 1500 * time.Millisecond, TTL: time.Hour}); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if model.Timeout.AsDuration() != 1500*time.Millisecond || model.TTL != int64(time.Hour) || model.Retry.AsDuration() != 0 {
+		t.Fatalf("unexpected model: %v", model)
+	}
+
+	// a nil duration leaves the destination untouched
+	entity := &durationEntity{Retry: time.Second}
+	if err := Copy(entity, &durationModel{Timeout: durationpb.New(time.Minute), TTL: 90}, DurationUnit(time.Second)); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if entity.Timeout != time.Minute || entity.TTL != 90*time.Second || entity.Retry != time.Second {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	millis := &struct{ Timeout, TTL int64 }{}
+	if err := Copy(millis, &durationModel{Timeout: durationpb.New(1500 * time.Millisecond), TTL: 7}, DurationUnit(time.Millisecond)); err != nil {
+		t.Fatalf("copy millis: %v", err)
+	}
+	if millis.Timeout != 1500 || millis.TTL != 7 {
+		t.Fatalf("unexpected millis: %+v", millis)
+	}
+	if err := Copy(millis, &durationModel{Timeout: durationpb.New(time.Second)}, RequireSupported()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported without a unit, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+		opts []Option
+	}{
+		{"invalid duration", &durationEntity{}, &durationModel{Timeout: &durationpb.Duration{Seconds: 1, Nanos: -1}}, nil},
+		{"duration over 10000 years", &durationEntity{}, &durationModel{Timeout: &durationpb.Duration{Seconds: 315576000001}}, nil},
+		{"duration over time.Duration", &durationEntity{}, &durationModel{Timeout: &durationpb.Duration{Seconds: 1 << 34}}, nil},
+		{"count over time.Duration", &durationEntity{}, &struct{ Timeout int64 }{Timeout: math.MaxInt64}, []Option{DurationUnit(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src, tt.opts...)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != "Timeout" || !errors.Is(err, ErrTimeRange) {
+				t.Fatalf("expected ErrTimeRange at Timeout, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package copier
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownEnum is the cause reported for a value copied into a registered
+// enum type that is not one of its values
+var ErrUnknownEnum = errors.New("unknown enum value")
+
+var (
+	// enums holds the values of every type added with RegisterEnum
+	enums   = make(map[reflect.Type]map[interface{}]bool)
+	enumsMu sync.RWMutex
+)
+
+// RegisterEnum adds values to the known values of their type, e.g.
+//
+//	copier.RegisterEnum(domain.Welcome, domain.SelfTemplate)
+//
+// Once a type is registered every value copied into it, or into a pointer to
+// it, must be one of its values, Copy fails with ErrUnknownEnum otherwise.
+// Include the zero value to accept it. It is safe to call RegisterEnum
+// concurrently with Copy.
+func RegisterEnum[T comparable](values ...T) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	defer resetCaches()
+	set, ok := enums[t]
+	if !ok {
+		set = make(map[interface{}]bool)
+		enums[t] = set
+	}
+	for _, v := range values {
+		set[v] = true
+	}
+}
+
+// CheckEnum returns an ErrUnknownEnum error when the type of v is registered
+// with RegisterEnum and v is not one of its values, nil otherwise
+func CheckEnum(v interface{}) error {
+	enumsMu.RLock()
+	set, ok := enums[reflect.TypeOf(v)]
+	known := set[v]
+	enumsMu.RUnlock()
+	if ok && !known {
+		return errors.Wrapf(ErrUnknownEnum, "%#v is not a %T", v, v)
+	}
+	return nil
+}
+
+// isEnum reports whether t or the type t points to is registered
+func isEnum(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	_, ok := enums[t]
+	return ok
+}
+
+// applyEnum applies r into a scratch copy of dst and sets dst only when the
+// written value is a known value of the enum type
+func applyEnum(s *state, r rule, dst, src reflect.Value, path string) (string, error) {
+	out := reflect.New(dst.Type()).Elem()
+	out.Set(dst)
+	r.enum = false
+	name, err := applyRule(s, r, out, src, path)
+	if err != nil {
+		return name, err
+	}
+
+	v := out
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			dst.Set(out)
+			return name, nil
+		}
+		v = v.Elem()
+	}
+	if err := CheckEnum(v.Interface()); err != nil {
+		return name, err
+	}
+	dst.Set(out)
+	return name, nil
+}
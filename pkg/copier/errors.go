@@ -0,0 +1,143 @@
+package copier
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoSource is the cause reported by RequireSource
+	ErrNoSource = errors.New("no source field")
+	// ErrNoDestination is the cause reported by RequireDestination
+	ErrNoDestination = errors.New("no destination field")
+	// ErrUnsupported is the cause reported by RequireSupported
+	ErrUnsupported = errors.New("unsupported type pair")
+	// ErrTooLong is the cause reported for slices and arrays over the length limit
+	ErrTooLong = errors.New("too many elements")
+	// ErrDuplicateKey is the cause reported for map keys converting to the same key
+	ErrDuplicateKey = errors.New("duplicate map key")
+	// ErrCycle is the cause reported for a struct pointer met again inside its
+	// own copy, the copy of a cyclic structure would never end
+	ErrCycle = errors.New("reference cycle")
+	// ErrOverflow is the cause reported for numbers the destination type can't
+	// hold, and for floats with a fractional part copied into an integer
+	ErrOverflow = errors.New("number out of range")
+)
+
+// CopyError describes why a single field could not be copied
+type CopyError struct {
+	// Path is the dotted path of the destination field, e.g. Items[3].Id. For
+	// ErrNoDestination, which has no destination field, it is the path of the
+	// source field.
+	Path string
+	// SrcType and DstType are the types of the source and destination fields,
+	// nil for a side that is missing
+	SrcType reflect.Type
+	DstType reflect.Type
+	// Value is the raw source value
+	Value interface{}
+	// Err is the underlying cause
+	Err error
+}
+
+func (e *CopyError) Error() string {
+	var types string
+	switch {
+	case e.SrcType != nil && e.DstType != nil:
+		types = fmt.Sprintf("%s -> %s", e.SrcType, e.DstType)
+	case e.SrcType != nil:
+		types = fmt.Sprintf("from %s", e.SrcType)
+	case e.DstType != nil:
+		types = fmt.Sprintf("to %s", e.DstType)
+	}
+	switch {
+	case e.Path != "" && types != "":
+		return fmt.Sprintf("copier: %s (%s): %v", e.Path, types, e.Err)
+	case e.Path != "" || types != "":
+		return fmt.Sprintf("copier: %s%s: %v", e.Path, types, e.Err)
+	}
+	return fmt.Sprintf("copier: %v", e.Err)
+}
+
+// Unwrap returns the underlying cause, for errors.Is and errors.As
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying cause, for errors.Cause
+func (e *CopyError) Cause() error {
+	return e.Err
+}
+
+// newCopyError wraps err with the field path and types, dst or src may be the
+// zero Value when that side is missing. Errors that already are a *CopyError
+// come from a deeper field and are returned as they are
+func newCopyError(path string, dst, src reflect.Value, err error) error {
+	if ce, ok := err.(*CopyError); ok {
+		return ce
+	}
+
+	ce := &CopyError{Path: path, Err: err}
+	if dst.IsValid() {
+		ce.DstType = dst.Type()
+	}
+	if src.IsValid() {
+		ce.SrcType = src.Type()
+		if src.CanInterface() {
+			ce.Value = src.Interface()
+		}
+	}
+	return ce
+}
+
+// panicError turns a recovered panic value into an error
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return errors.WithStack(err)
+	}
+	return errors.Errorf("%v", r)
+}
+
+// Errors holds every field failure of a copy made with CollectErrors
+type Errors []*CopyError
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("copier: %d field(s) failed: [%s]", len(es), strings.Join(msgs, "; "))
+}
+
+// Is reports whether one of the field errors matches target. errors.Is only
+// follows Unwrap() []error from Go 1.20, this method covers the older releases.
+func (es Errors) Is(target error) bool {
+	for _, e := range es {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As sets target to the first field error that matches it, see Is
+func (es Errors) As(target interface{}) bool {
+	for _, e := range es {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the field errors, for errors.Is and errors.As from Go 1.20
+func (es Errors) Unwrap() []error {
+	errs := make([]error, len(es))
+	for i, e := range es {
+		errs[i] = e
+	}
+	return errs
+}
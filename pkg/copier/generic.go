@@ -0,0 +1,33 @@
+package copier
+
+import "reflect"
+
+// To copies src into a new value of type D and returns it. D is a struct or
+// a pointer to a struct, a pointer is allocated:
+//
+//	model, err := copier.To[*v1.MaterialGroupModel](group)
+func To[D any](src interface{}, opts ...Option) (D, error) {
+	var dst D
+	if t := reflect.TypeOf(dst); t != nil && t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		err := Copy(v.Interface(), src, opts...)
+		return v.Interface().(D), err
+	}
+	err := Copy(&dst, src, opts...)
+	return dst, err
+}
+
+// Into is Copy with typed arguments, so a destination that isn't a pointer is
+// caught at compile time. Copy keeps its untyped signature for existing callers.
+func Into[D, S any](dst *D, src S, opts ...Option) error {
+	return Copy(dst, src, opts...)
+}
+
+// Slice copies every element of src into a new []D, see CopySlice
+//
+//	models, err := copier.Slice[*v1.MaterialGroupModel](groups)
+func Slice[D, S any](src []S, opts ...Option) ([]D, error) {
+	var dst []D
+	err := CopySlice(&dst, src, opts...)
+	return dst, err
+}
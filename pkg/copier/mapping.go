@@ -0,0 +1,287 @@
+package copier
+
+import "reflect"
+
+// fieldMapping pairs a destination field with the source field it is copied from
+type fieldMapping struct {
+	dst reflect.StructField
+	src reflect.StructField
+	// hasSrc is false when no source field was found
+	hasSrc bool
+	// ignored is true when a copier tag keeps the destination from being written
+	ignored bool
+	// rule is how the source field type is copied into the destination field type
+	rule rule
+	// zero is the zero= tag of the destination field, or else of the source field
+	zero zeroTag
+	// srcCase is set when the source is a oneof case, src then is the oneof field
+	srcCase *oneofCase
+}
+
+// structMapping is the field pairing between a destination and a source struct type
+type structMapping struct {
+	fields []fieldMapping
+	// unused holds the readable source fields that no destination picked
+	unused []reflect.StructField
+}
+
+// mapStruct pairs the exported fields of dstType with the fields of srcType.
+// For every destination field, in order:
+//   - a from list or a name in its copier tag picks the first source field with one of those names
+//   - otherwise a source field whose to list or tag name names it is used
+//   - otherwise the first source field found by the match strategies, skipping
+//     source fields tagged to go elsewhere
+//
+// The cases of the oneofs of a generated message are matched as fields of
+// their own, see mapOneofs.
+func mapStruct(dstType, srcType reflect.Type, match []MatchStrategy) *structMapping {
+	dstCases, srcCases := oneofCases(dstType), oneofCases(srcType)
+	// by name lookups also find fields promoted from embedded structs
+	byName := func(name string) (reflect.StructField, bool) {
+		if f, ok := srcType.FieldByName(name); ok {
+			return f, true
+		}
+		if c := srcCases.byName(name); c != nil {
+			return c.structField(), true
+		}
+		return reflect.StructField{}, false
+	}
+	m := matchFields(append(fieldsOf(dstType), dstCases.fields()...), append(fieldsOf(srcType), srcCases.fields()...), byName, match)
+	for i := range m.fields {
+		if fm := &m.fields[i]; fm.hasSrc {
+			fm.rule = ruleFor(fm.src.Type, fm.dst.Type)
+			fm.zero = zeroOf(fm.dst, fm.src)
+			fm.rule.oneof = dstCases.byName(fm.dst.Name)
+			fm.srcCase = srcCases.byName(fm.src.Name)
+		}
+	}
+	mapOneofs(m, dstCases, srcCases)
+	return m
+}
+
+// mapOneofs drops the oneof cases that are not copied. A oneof field of the
+// destination is written through its cases only when it has no source of its
+// own, and is left out once one of its cases has a source. A oneof field of
+// the source is not unused once one of its cases is copied.
+func mapOneofs(m *structMapping, dstCases, srcCases oneofCaseList) {
+	if dstCases == nil && srcCases == nil {
+		return
+	}
+
+	mapped := make(map[string]bool)
+	for _, fm := range m.fields {
+		if fm.hasSrc && dstCases.byName(fm.dst.Name) == nil {
+			mapped[fm.dst.Name] = true
+		}
+	}
+	written := make(map[string]bool)
+	read := make(map[string]bool)
+	fields := m.fields[:0]
+	for _, fm := range m.fields {
+		if c := dstCases.byName(fm.dst.Name); c != nil {
+			if !fm.hasSrc || mapped[c.field.Name] {
+				continue
+			}
+			written[c.field.Name] = true
+		}
+		if fm.srcCase != nil {
+			read[fm.srcCase.field.Name] = true
+		}
+		fields = append(fields, fm)
+	}
+	m.fields = fields[:0]
+	for _, fm := range fields {
+		if !fm.hasSrc && written[fm.dst.Name] {
+			continue
+		}
+		m.fields = append(m.fields, fm)
+	}
+
+	unused := m.unused[:0]
+	for _, f := range m.unused {
+		if srcCases.byName(f.Name) == nil && !read[f.Name] {
+			unused = append(unused, f)
+		}
+	}
+	m.unused = unused
+}
+
+func fieldsOf(t reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i)
+	}
+	return fields
+}
+
+// matchFields does the pairing of mapStruct on plain field lists, byName looks
+// a source field up by Go name. Only the Name, PkgPath and Tag of the fields are read.
+func matchFields(dstFields, srcFields []reflect.StructField, byName func(string) (reflect.StructField, bool), match []MatchStrategy) *structMapping {
+	srcTags := make(map[string]fieldTag)
+	targeted := make(map[string]reflect.StructField)
+	// keyed holds the untagged readable source fields by their strategy keys
+	keyed := make(map[string]reflect.StructField)
+	for _, f := range srcFields {
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := parseTag(f)
+		srcTags[f.Name] = tag
+		if !tag.readable() {
+			continue
+		}
+		for _, name := range tag.targets() {
+			if _, ok := targeted[name]; !ok {
+				targeted[name] = f
+			}
+		}
+		if tag.targets() != nil {
+			continue
+		}
+		for _, strategy := range match {
+			if key := strategy.key(f); key != "" {
+				if _, ok := keyed[key]; !ok {
+					keyed[key] = f
+				}
+			}
+		}
+	}
+
+	// srcField finds an exported, readable source field by Go name
+	srcField := func(name string) (reflect.StructField, bool) {
+		f, ok := byName(name)
+		if !ok || f.PkgPath != "" {
+			return f, false
+		}
+		tag, ok := srcTags[f.Name]
+		if !ok {
+			tag = parseTag(f)
+		}
+		return f, tag.readable()
+	}
+
+	m := &structMapping{}
+	used := make(map[string]bool)
+	for _, f := range dstFields {
+		if f.PkgPath != "" {
+			continue
+		}
+		fm := fieldMapping{dst: f}
+		tag := parseTag(f)
+
+		switch {
+		case tag.sources() != nil:
+			for _, name := range tag.sources() {
+				if fm.src, fm.hasSrc = srcField(name); fm.hasSrc {
+					break
+				}
+			}
+		default:
+			if src, ok := targeted[f.Name]; ok {
+				fm.src, fm.hasSrc = src, true
+				break
+			}
+			for _, strategy := range match {
+				if strategy == MatchName {
+					if src, ok := srcField(f.Name); ok && srcTags[src.Name].targets() == nil {
+						fm.src, fm.hasSrc = src, true
+						break
+					}
+					continue
+				}
+				if key := strategy.key(f); key != "" {
+					if src, ok := keyed[key]; ok {
+						fm.src, fm.hasSrc = src, true
+						break
+					}
+				}
+			}
+		}
+
+		if fm.hasSrc {
+			used[fm.src.Name] = true
+		}
+		// an ignored field is still matched, so that its source is not unused
+		if !tag.writable() {
+			fm = fieldMapping{dst: f, ignored: true}
+		}
+		m.fields = append(m.fields, fm)
+	}
+
+	for _, f := range srcFields {
+		if f.PkgPath != "" || used[f.Name] || !srcTags[f.Name].readable() {
+			continue
+		}
+		m.unused = append(m.unused, f)
+	}
+
+	return m
+}
+
+// zeroOf returns the zero= tag of dst, or else of src
+func zeroOf(dst, src reflect.StructField) zeroTag {
+	if zero := parseTag(dst).zero; zero != zeroUnset {
+		return zero
+	}
+	return parseTag(src).zero
+}
+
+// FieldMatch is the outcome of MatchFields for one destination field
+type FieldMatch struct {
+	// Src is the index of the source field in the src list, -1 when there is none
+	Src int
+	// Ignored is true when the destination field is never written, because a
+	// copier tag says so or because it is unexported
+	Ignored bool
+	// Zero is the zero= item of the copier tags of the pair, "nil", "keep" or
+	// empty when neither field has one
+	Zero string
+}
+
+// MatchFields pairs destination fields with source fields the way Copy does,
+// by copier tags and then by the match strategies, DefaultMatch when none are
+// given. Only the Name, PkgPath and Tag of the fields are read, so it suits
+// code generators that describe fields without a reflect.Type. The result
+// holds one FieldMatch per destination field, in order.
+func MatchFields(dst, src []reflect.StructField, strategies ...MatchStrategy) []FieldMatch {
+	if len(strategies) == 0 {
+		strategies = DefaultMatch
+	}
+
+	index := make(map[string]int, len(src))
+	for i, f := range src {
+		index[f.Name] = i
+	}
+	byName := func(name string) (reflect.StructField, bool) {
+		i, ok := index[name]
+		if !ok {
+			return reflect.StructField{}, false
+		}
+		return src[i], true
+	}
+
+	m := matchFields(dst, src, byName, strategies)
+	matches := make([]FieldMatch, len(dst))
+	next := 0
+	for i, f := range dst {
+		matches[i] = FieldMatch{Src: -1, Ignored: f.PkgPath != ""}
+		if f.PkgPath != "" {
+			continue
+		}
+		fm := m.fields[next]
+		next++
+		switch {
+		case fm.ignored:
+			matches[i].Ignored = true
+		case fm.hasSrc:
+			matches[i].Src = index[fm.src.Name]
+			switch zeroOf(fm.dst, fm.src) {
+			case zeroNil:
+				matches[i].Zero = "nil"
+			case zeroKeep:
+				matches[i].Zero = "keep"
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,102 @@
+package copier
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MatchStrategy is a way of pairing a destination field with a source field
+// that has no copier tag linking them
+type MatchStrategy int
+
+const (
+	// MatchName pairs fields with the same Go name
+	MatchName MatchStrategy = iota
+	// MatchNameFold pairs fields whose Go names are equal ignoring case
+	MatchNameFold
+	// MatchNormalized pairs fields whose names are equal ignoring case and
+	// underscores, so OrgId, orgId and org_id all match
+	MatchNormalized
+	// MatchBSONTag pairs fields by their bson tag name, `_id` is read as `id`
+	MatchBSONTag
+	// MatchJSONTag pairs fields by their json tag name
+	MatchJSONTag
+	// MatchProtoName pairs fields by the name in their protobuf tag
+	MatchProtoName
+	// MatchProtoNumber pairs fields by the field number in their protobuf tag
+	MatchProtoNumber
+)
+
+// DefaultMatch is the strategy list used without the MatchBy option. Go names
+// win, the bson, json and protobuf tag names are tried after them.
+var DefaultMatch = []MatchStrategy{MatchName, MatchBSONTag, MatchJSONTag, MatchProtoName}
+
+// key returns the name field is known by under the strategy, empty when it has none.
+// Keys carry a prefix per kind of name, tag strategies share one prefix so a
+// bson name can pair with a protobuf name.
+func (m MatchStrategy) key(field reflect.StructField) string {
+	var key string
+	switch m {
+	case MatchName:
+		return "go:" + field.Name
+	case MatchNameFold:
+		return "fold:" + strings.ToLower(field.Name)
+	case MatchNormalized:
+		return "norm:" + normalizeName(field.Name)
+	case MatchBSONTag:
+		if key = tagValue(field, "bson"); key == "_id" {
+			key = "id"
+		}
+	case MatchJSONTag:
+		key = tagValue(field, "json")
+	case MatchProtoName:
+		key = protoTagItem(field, "name=")
+	case MatchProtoNumber:
+		if n := protoTagNumber(field); n != "" {
+			return "number:" + n
+		}
+	}
+	if key == "" {
+		return ""
+	}
+	return "tag:" + key
+}
+
+// normalizeName lower-cases name and drops underscores and dashes
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+// tagValue returns the name part of a bson or json style tag
+func tagValue(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// protoTagItem returns the value of a prefix= item of the protobuf tag
+func protoTagItem(field reflect.StructField, prefix string) string {
+	for _, item := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(item, prefix) {
+			return strings.TrimPrefix(item, prefix)
+		}
+	}
+	return ""
+}
+
+// protoTagNumber returns the field number of the protobuf tag, the second item
+func protoTagNumber(field reflect.StructField) string {
+	items := strings.Split(field.Tag.Get("protobuf"), ",")
+	if len(items) < 2 {
+		return ""
+	}
+	for _, r := range items[1] {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return items[1]
+}
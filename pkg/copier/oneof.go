@@ -0,0 +1,225 @@
+package copier
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrOneof is the cause reported for a oneof that can't be written: a value
+// with no registered case, or two fields setting the same oneof
+var ErrOneof = errors.New("invalid oneof")
+
+// protoMessage is the interface of every generated message pointer
+var protoMessage = reflect.TypeOf((*protoreflect.ProtoMessage)(nil)).Elem()
+
+// oneofCase is one member of a oneof of a generated message
+type oneofCase struct {
+	// field is the interface field of the message holding the oneof
+	field reflect.StructField
+	// wrapper is the generated case type, e.g. *structpb.Value_StringValue
+	wrapper reflect.Type
+	// value is the field of the wrapper holding the value of the case
+	value reflect.StructField
+}
+
+// structField describes the case as a field of the message, under the name
+// and tag of its value and the index of the oneof field
+func (c *oneofCase) structField() reflect.StructField {
+	return reflect.StructField{Name: c.value.Name, Type: c.value.Type, Tag: c.value.Tag, Index: c.field.Index}
+}
+
+// get returns the value of the case from the oneof field v, ok is false when
+// the oneof holds another case or none
+func (c *oneofCase) get(v reflect.Value) (value reflect.Value, ok bool) {
+	if v.IsNil() || v.Elem().Type() != c.wrapper || v.Elem().IsNil() {
+		return reflect.Value{}, false
+	}
+	return v.Elem().Elem().FieldByIndex(c.value.Index), true
+}
+
+// oneofCaseList holds the oneof cases of a message, in declaration order
+type oneofCaseList []*oneofCase
+
+// fields describes every case as a field of the message
+func (l oneofCaseList) fields() []reflect.StructField {
+	fields := make([]reflect.StructField, len(l))
+	for i, c := range l {
+		fields[i] = c.structField()
+	}
+	return fields
+}
+
+// byName returns the case whose value field is called name, nil if there is none
+func (l oneofCaseList) byName(name string) *oneofCase {
+	for _, c := range l {
+		if c.value.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// messageCases caches oneofCases by struct type
+var messageCases sync.Map
+
+// oneofCases lists the oneof cases of a generated message struct type, nil for
+// other types. The wrapper types are found by setting each case through
+// protoreflect, the optional fields of proto3 are not oneofs here.
+// Descriptors are only read for messages with a protobuf_oneof field, they
+// are not registered before the init of the generated package has run.
+func oneofCases(t reflect.Type) oneofCaseList {
+	if cases, ok := messageCases.Load(t); ok {
+		return cases.(oneofCaseList)
+	}
+
+	var cases oneofCaseList
+	if t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(protoMessage) && hasOneof(t) {
+		msg := reflect.New(t)
+		m := msg.Interface().(protoreflect.ProtoMessage).ProtoReflect()
+		oneofs := m.Descriptor().Oneofs()
+		for i := 0; i < oneofs.Len(); i++ {
+			od := oneofs.Get(i)
+			field, ok := oneofField(t, od.Name())
+			if od.IsSynthetic() || !ok {
+				continue
+			}
+			for j := 0; j < od.Fields().Len(); j++ {
+				fd := od.Fields().Get(j)
+				m.Set(fd, m.NewField(fd))
+				wrapper := msg.Elem().FieldByIndex(field.Index).Elem().Type()
+				cases = append(cases, &oneofCase{field: field, wrapper: wrapper, value: wrapper.Elem().Field(0)})
+			}
+		}
+	}
+	messageCases.Store(t, cases)
+	return cases
+}
+
+// hasOneof reports whether the struct type t has a protobuf_oneof field
+func hasOneof(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("protobuf_oneof"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// oneofField finds the interface field of the oneof called name
+func oneofField(t reflect.Type, name protoreflect.Name) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Tag.Get("protobuf_oneof") == string(name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// applyOneofCase applies r into a new wrapper of the case and sets the oneof
+// field dst to it, dst is left untouched when r fails or is not supported
+func applyOneofCase(s *state, r rule, dst, src reflect.Value, path string) (string, error) {
+	c := r.oneof
+	w := reflect.New(c.wrapper.Elem())
+	r.oneof = nil
+	name, err := applyRule(s, r, w.Elem().FieldByIndex(c.value.Index), src, path)
+	if err != nil {
+		return name, err
+	}
+	dst.Set(w)
+	return name, nil
+}
+
+// oneofLink is a pair added with RegisterOneofCase
+type oneofLink struct {
+	domain  reflect.Type
+	wrapper reflect.Type
+}
+
+var (
+	// oneofLinks holds the pairs added with RegisterOneofCase, in order
+	oneofLinks   []oneofLink
+	oneofLinksMu sync.RWMutex
+)
+
+// RegisterOneofCase links the domain type D with the oneof case C, a generated
+// wrapper such as *structpb.Value_StringValue:
+//
+//	copier.RegisterOneofCase[domain.TextContent, *structpb.Value_StringValue]()
+//
+// A D held by an interface field is then copied into the oneof field the
+// wrapper belongs to, and a C found in a oneof field is copied into an
+// interface field that D implements. The value of the case is copied from or
+// into D with the usual rules. It is safe to call RegisterOneofCase
+// concurrently with Copy.
+func RegisterOneofCase[D any, C any]() {
+	domain, wrapper := reflect.TypeOf((*D)(nil)).Elem(), reflect.TypeOf((*C)(nil)).Elem()
+	if domain.Kind() == reflect.Interface {
+		panic("copier: RegisterOneofCase with interface type " + domain.String())
+	}
+	if wrapper.Kind() != reflect.Ptr || wrapper.Elem().Kind() != reflect.Struct || wrapper.Elem().NumField() != 1 {
+		panic("copier: RegisterOneofCase with " + wrapper.String() + ", which is not a oneof case")
+	}
+
+	oneofLinksMu.Lock()
+	defer oneofLinksMu.Unlock()
+	defer resetCaches()
+	for _, l := range oneofLinks {
+		if l.domain == domain && l.wrapper == wrapper {
+			return
+		}
+	}
+	oneofLinks = append(oneofLinks, oneofLink{domain: domain, wrapper: wrapper})
+}
+
+// isOneofPair reports whether src and dst are two interface types and one
+// of them is implemented by a registered oneof case
+func isOneofPair(src, dst reflect.Type) bool {
+	if src.Kind() != reflect.Interface || dst.Kind() != reflect.Interface || src == dst {
+		return false
+	}
+	oneofLinksMu.RLock()
+	defer oneofLinksMu.RUnlock()
+	for _, l := range oneofLinks {
+		if src.NumMethod() > 0 && l.wrapper.Implements(src) || dst.NumMethod() > 0 && l.wrapper.Implements(dst) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyOneof copies between a oneof field and a domain interface field through
+// the registered cases, a nil src leaves dst untouched
+func copyOneof(s *state, dst, src reflect.Value, path string) error {
+	if src.IsNil() || src.Elem().Kind() == reflect.Ptr && src.Elem().IsNil() {
+		return nil
+	}
+	v := src.Elem()
+
+	oneofLinksMu.RLock()
+	links := oneofLinks
+	oneofLinksMu.RUnlock()
+	for _, l := range links {
+		switch {
+		case v.Type() == l.domain && l.wrapper.Implements(dst.Type()):
+			w := reflect.New(l.wrapper.Elem())
+			if _, err := copyValue(s, w.Elem().Field(0), v, path); err != nil {
+				return err
+			}
+			dst.Set(w)
+			return nil
+		case v.Type() == l.wrapper && l.domain.Implements(dst.Type()):
+			out := reflect.New(l.domain).Elem()
+			if value := v.Elem().Field(0); value.Kind() != reflect.Ptr || !value.IsNil() {
+				if _, err := copyValue(s, out, value, path); err != nil {
+					return err
+				}
+			}
+			dst.Set(out)
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrOneof, "no oneof case is registered for %v into %v", v.Type(), dst.Type())
+}
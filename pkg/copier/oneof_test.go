@@ -0,0 +1,102 @@
+package copier
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// content is a domain union over some of the cases of structpb.Value
+type content interface{ isContent() }
+
+type text string
+
+type number float64
+
+type list struct{ Values []*structpb.Value }
+
+type flag bool
+
+func (text) isContent()   {}
+func (number) isContent() {}
+func (list) isContent()   {}
+func (flag) isContent()   {}
+
+type contentEntity struct {
+	Kind content
+}
+
+// contentFields holds the cases of structpb.Value as separate fields
+type contentFields struct {
+	NumberValue float64
+	Text        string `copier:"StringValue"`
+	ListValue   *structpb.ListValue
+}
+
+func Test_CopyOneofs(t *testing.T) {
+	RegisterOneofCase[text, *structpb.Value_StringValue]()
+	RegisterOneofCase[number, *structpb.Value_NumberValue]()
+	RegisterOneofCase[list, *structpb.Value_ListValue]()
+
+	// an interface field maps to the case registered for its dynamic type
+	for _, kind := range []content{text("hi"), number(2.5), list{Values: []*structpb.Value{structpb.NewStringValue("a")}}} {
+		value := &structpb.Value{}
+		if err := Copy(value, &contentEntity{Kind: kind}); err != nil {
+			t.Fatalf("copy %T: %v", kind, err)
+		}
+		entity := &contentEntity{}
+		if err := Copy(entity, value); err != nil {
+			t.Fatalf("copy value %v: %v", value, err)
+		}
+		if l, ok := entity.Kind.(list); ok {
+			if len(l.Values) != 1 || l.Values[0].GetStringValue() != "a" {
+				t.Fatalf("unexpected list: %v", l)
+			}
+			continue
+		}
+		if entity.Kind != kind {
+			t.Fatalf("copied %v back as %v", kind, entity.Kind)
+		}
+	}
+
+	// separate fields map to the cases, a case that is not set is skipped
+	fields := &contentFields{Text: "keep"}
+	report, err := CopyWithReport(fields, structpb.NewNumberValue(1.5))
+	if err != nil {
+		t.Fatalf("copy number value: %v", err)
+	}
+	if fields.NumberValue != 1.5 || fields.Text != "keep" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+	if f, _ := report.Field("Text"); f.Reason != ReasonOneofUnset {
+		t.Fatalf("unexpected report for Text: %+v", f)
+	}
+	value := &structpb.Value{}
+	if err := Copy(value, &contentFields{Text: "hi"}); err != nil {
+		t.Fatalf("copy fields: %v", err)
+	}
+	if value.GetStringValue() != "hi" {
+		t.Fatalf("unexpected value: %v", value)
+	}
+
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+		path string
+	}{
+		{"two fields", &structpb.Value{}, &contentFields{NumberValue: 1, Text: "hi"}, "StringValue"},
+		{"unregistered domain type", &structpb.Value{}, &contentEntity{Kind: flag(true)}, "Kind"},
+		{"unregistered case", &contentEntity{}, structpb.NewBoolValue(true), "Kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != tt.path || !errors.Is(err, ErrOneof) {
+				t.Fatalf("expected ErrOneof at %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
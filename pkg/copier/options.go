@@ -0,0 +1,305 @@
+package copier
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures a single Copy call
+type Option func(*options)
+
+type options struct {
+	// collectErrors keeps copying after a failing field
+	collectErrors bool
+
+	// match lists the field matching strategies in priority order,
+	// matchKey is their fingerprint for the plan cache
+	match    []MatchStrategy
+	matchKey string
+
+	// maxLength limits the number of slice and map elements, 0 means no limit
+	maxLength int
+
+	// nilMaps is what a nil source map becomes
+	nilMaps NilMapPolicy
+
+	// zeroWrappers is what a zero value becomes in a wrapperspb destination
+	zeroWrappers ZeroWrapperPolicy
+
+	// zeroPointers is what a zero value becomes in a scalar pointer destination
+	zeroPointers ZeroPointerPolicy
+
+	// time policy, see the Time options
+	zeroTimes     ZeroTimePolicy
+	timePrecision time.Duration
+	timeZone      *time.Location
+	timeLayout    string
+	unixTime      time.Duration
+	durationUnit  time.Duration
+	objectIDTime  bool
+
+	// proto enum names, see EnumPrefix and EnumNames
+	enumPrefixes []string
+	enumNames    map[string]string
+
+	// dryRun copies into a scratch destination, leaving dst untouched
+	dryRun bool
+
+	// strict checks, each with the field names or paths it lets through
+	requireSource      allowlist
+	requireDestination allowlist
+	requireSupported   allowlist
+}
+
+// allowlist holds the field names and dotted paths exempt from a strict check,
+// a nil allowlist means the check is off
+type allowlist map[string]bool
+
+func (l allowlist) with(fields []string) allowlist {
+	if l == nil {
+		l = make(allowlist)
+	}
+	for _, f := range fields {
+		l[f] = true
+	}
+	return l
+}
+
+// enforced reports whether the check applies to the field name at path
+func (l allowlist) enforced(name, path string) bool {
+	return l != nil && !l[name] && !l[path]
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{match: DefaultMatch}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.matchKey = fmt.Sprint(o.match)
+	return o
+}
+
+// CollectErrors makes Copy try every field and return all failures at once as
+// Errors, instead of stopping at the first one
+func CollectErrors() Option {
+	return func(o *options) {
+		o.collectErrors = true
+	}
+}
+
+// MatchBy replaces DefaultMatch with the given field matching strategies, tried
+// in order for every destination field that no copier tag links to a source
+func MatchBy(strategies ...MatchStrategy) Option {
+	return func(o *options) {
+		o.match = strategies
+	}
+}
+
+// MaxLength fails slices and maps copied element by element with more than n
+// elements with ErrTooLong, instead of copying them. Arrays have a fixed size
+// and are not limited.
+func MaxLength(n int) Option {
+	return func(o *options) {
+		o.maxLength = n
+	}
+}
+
+// NilMapPolicy decides what a nil source map becomes, an empty source map
+// always becomes an empty destination map
+type NilMapPolicy int
+
+const (
+	// NilMapSkip leaves the destination map untouched, the default
+	NilMapSkip NilMapPolicy = iota
+	// NilMapToNil sets the destination map to nil
+	NilMapToNil
+	// NilMapToEmpty sets the destination to a new empty map
+	NilMapToEmpty
+)
+
+// NilMaps sets the policy for nil source maps
+func NilMaps(policy NilMapPolicy) Option {
+	return func(o *options) {
+		o.nilMaps = policy
+	}
+}
+
+// ZeroWrapperPolicy decides what a zero value becomes when the destination is
+// a wrapperspb message. A pointer source always becomes a wrapper, its
+// presence is what it carries.
+type ZeroWrapperPolicy int
+
+const (
+	// ZeroWrapperKeep wraps the zero value, the default
+	ZeroWrapperKeep ZeroWrapperPolicy = iota
+	// ZeroWrapperToNil sets the destination to nil, the field is absent
+	ZeroWrapperToNil
+)
+
+// ZeroWrappers sets the policy for zero values copied into wrappers, a
+// copier:"zero=nil" or "zero=keep" tag overrides it for a single field
+func ZeroWrappers(policy ZeroWrapperPolicy) Option {
+	return func(o *options) {
+		o.zeroWrappers = policy
+	}
+}
+
+// ZeroPointerPolicy decides what a zero value becomes when the destination is
+// a pointer to a bool, number or string, such as the *string of a proto3
+// optional field. A pointer source keeps its presence, a nil one is skipped.
+type ZeroPointerPolicy int
+
+const (
+	// ZeroPointerKeep points to the zero value, the default
+	ZeroPointerKeep ZeroPointerPolicy = iota
+	// ZeroPointerToNil sets the destination to nil, the field is unset
+	ZeroPointerToNil
+)
+
+// ZeroPointers sets the policy for zero values copied into scalar pointers, a
+// copier:"zero=nil" or "zero=keep" tag overrides it for a single field
+func ZeroPointers(policy ZeroPointerPolicy) Option {
+	return func(o *options) {
+		o.zeroPointers = policy
+	}
+}
+
+// ZeroTimePolicy decides what a zero time.Time becomes when the destination is
+// a *timestamppb.Timestamp or a *time.Time, or a string or int64 under
+// TimeLayout and UnixTime. With ZeroTimeToNil an empty string and 0 are read
+// back as no time.
+type ZeroTimePolicy int
+
+const (
+	// ZeroTimeToNil sets the destination to nil, the default
+	ZeroTimeToNil ZeroTimePolicy = iota
+	// ZeroTimeKeep converts the zero time like any other,
+	// 0001-01-01T00:00:00Z for a Timestamp
+	ZeroTimeKeep
+)
+
+// ZeroTimes sets the policy for zero times, a copier:"zero=nil" or
+// "zero=keep" tag overrides it for a single field
+func ZeroTimes(policy ZeroTimePolicy) Option {
+	return func(o *options) {
+		o.zeroTimes = policy
+	}
+}
+
+// TimePrecision truncates every converted time to a multiple of d, e.g.
+// time.Millisecond to match what Mongo stores. 0 keeps nanoseconds.
+func TimePrecision(d time.Duration) Option {
+	return func(o *options) {
+		o.timePrecision = d
+	}
+}
+
+// TimeZone sets the location of every converted time, time.UTC or a named
+// location from time.LoadLocation. Strings without a zone are parsed in it.
+func TimeZone(loc *time.Location) Option {
+	return func(o *options) {
+		o.timeZone = loc
+	}
+}
+
+// TimeLayout converts times to and from strings formatted with layout, e.g.
+// time.RFC3339. Without it a time and a string don't convert.
+func TimeLayout(layout string) Option {
+	return func(o *options) {
+		o.timeLayout = layout
+	}
+}
+
+// UnixTime converts times to and from int64 counts of unit since the unix
+// epoch, e.g. time.Second or time.Millisecond. Without it a time and an int64
+// don't convert.
+func UnixTime(unit time.Duration) Option {
+	return func(o *options) {
+		o.unixTime = unit
+	}
+}
+
+// ObjectIDTime converts ObjectIDs into times, the creation time embedded in
+// the id with a precision of one second. Without it an ObjectID and a time
+// don't convert.
+func ObjectIDTime() Option {
+	return func(o *options) {
+		o.objectIDTime = true
+	}
+}
+
+// DurationUnit converts time.Duration and durationpb.Duration to and from
+// int64 counts of unit, e.g. time.Millisecond or time.Second. Without it a
+// time.Duration converts as nanoseconds and a durationpb.Duration doesn't
+// convert to an int64.
+func DurationUnit(unit time.Duration) Option {
+	return func(o *options) {
+		o.durationUnit = unit
+	}
+}
+
+// EnumPrefix sets the prefixes stripped from proto enum value names before
+// they are lower cased into Go strings, the first that matches is used. By
+// default it is the enum name in upper snake case, so MATERIAL_GROUP_SCOPE_ORG
+// of MaterialGroupScope becomes "org".
+func EnumPrefix(prefixes ...string) Option {
+	return func(o *options) {
+		o.enumPrefixes = append(o.enumPrefixes, prefixes...)
+	}
+}
+
+// EnumNames maps proto enum value names to the Go strings they convert to and
+// from, e.g. {"MATERIAL_GROUP_TYPE_SELF": "self_template"}. Values missing
+// from names go through EnumPrefix, the zero value becomes the empty string.
+func EnumNames(names map[string]string) Option {
+	return func(o *options) {
+		if o.enumNames == nil {
+			o.enumNames = make(map[string]string, len(names))
+		}
+		for k, v := range names {
+			o.enumNames[k] = v
+		}
+	}
+}
+
+// DryRun makes the copy run against a zero scratch value of the destination
+// type, so dst is never written. It is meant for CopyWithReport.
+func DryRun() Option {
+	return func(o *options) {
+		o.dryRun = true
+	}
+}
+
+// Strict turns on RequireSource, RequireDestination and RequireSupported, so a
+// mapping that would silently drop a field fails instead
+func Strict() Option {
+	return func(o *options) {
+		RequireSource()(o)
+		RequireDestination()(o)
+		RequireSupported()(o)
+	}
+}
+
+// RequireSource fails with ErrNoSource for destination fields that have no
+// source field, except the given field names or dotted paths
+func RequireSource(except ...string) Option {
+	return func(o *options) {
+		o.requireSource = o.requireSource.with(except)
+	}
+}
+
+// RequireDestination fails with ErrNoDestination for source fields that are
+// not copied anywhere, except the given field names or dotted paths
+func RequireDestination(except ...string) Option {
+	return func(o *options) {
+		o.requireDestination = o.requireDestination.with(except)
+	}
+}
+
+// RequireSupported fails with ErrUnsupported for fields whose type pair has no
+// conversion rule, except the given field names or dotted paths
+func RequireSupported(except ...string) Option {
+	return func(o *options) {
+		o.requireSupported = o.requireSupported.with(except)
+	}
+}
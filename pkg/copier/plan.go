@@ -0,0 +1,295 @@
+package copier
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// ruleKind is the way a value of one type is copied into another
+type ruleKind int
+
+const (
+	ruleUnsupported ruleKind = iota
+	ruleConverter
+	ruleAssign
+	ruleConvert
+	ruleNested
+	ruleSequence
+	ruleMap
+	ruleOneof
+	rulePointer
+	ruleClone
+)
+
+// rule is the resolved way of copying a (src, dst) type pair
+type rule struct {
+	kind ruleKind
+	conv *converter
+	// enum is true when dst is a registered enum type, see RegisterEnum
+	enum bool
+	// oneof is set when dst is a oneof field written through one of its
+	// cases, the rest of the rule copies into the value of the case
+	oneof *oneofCase
+}
+
+// name is the conversion reported for the rule, empty for a plain assignment
+// or a clone
+func (r rule) name() string {
+	switch r.kind {
+	case ruleConverter:
+		return r.conv.name
+	case ruleConvert:
+		return goConversion
+	case ruleNested:
+		return nestedCopy
+	case ruleSequence, ruleMap:
+		return elementCopy
+	case ruleOneof:
+		return oneofCopy
+	}
+	return ""
+}
+
+// nests reports whether the rule copies into fields or elements below the
+// value, which are recorded and fail under their own path
+func (r rule) nests() bool {
+	switch r.kind {
+	case ruleNested, ruleSequence, ruleMap, ruleOneof:
+		return true
+	}
+	return r.oneof != nil
+}
+
+// resolveRule picks the rule for a type pair: a converter registered for the
+// exact (src, dst) pair wins, then the proto enum conversions, then a
+// built-in wrapper or ObjectID converter for their base types when either is
+// a named basic type, then the
+// registered oneof cases between two interfaces, then Go assignability, then a
+// Go conversion between basic kinds of the same family, then the rule of the
+// scalars behind scalar pointers, then a field by field copy between structs
+// and pointers to structs, then an element by element copy between slices and
+// arrays, or between maps, whose elements and keys have a rule. Integer and
+// string map keys convert into each other on top of the rules.
+// Slices and maps are not assigned, so that dst never shares them with src: a
+// slice of the same type is cloned when its elements hold no pointers, and
+// copied element by element otherwise.
+// Values written into a registered enum type are checked, whatever the rule.
+func resolveRule(src, dst reflect.Type) rule {
+	r := resolveKind(src, dst, map[typePair]bool{{src: src, dst: dst}: true})
+	r.enum = isEnum(dst)
+	return r
+}
+
+// resolveKind resolves the kind of rule, seen holds the sequence and map pairs
+// whose elements are being resolved
+func resolveKind(src, dst reflect.Type, seen map[typePair]bool) rule {
+	switch {
+	case lookupConverter(src, dst) != nil:
+		return rule{kind: ruleConverter, conv: lookupConverter(src, dst)}
+	case protoEnumConverter(src, dst) != nil:
+		return rule{kind: ruleConverter, conv: protoEnumConverter(src, dst)}
+	case baseConverter(src, dst) != nil:
+		return rule{kind: ruleConverter, conv: baseConverter(src, dst)}
+	case isOneofPair(src, dst):
+		return rule{kind: ruleOneof}
+	case src.AssignableTo(dst) && !isShared(src, dst):
+		return rule{kind: ruleAssign}
+	case src.AssignableTo(dst) && src.Kind() == reflect.Slice && isFlat(src.Elem()):
+		return rule{kind: ruleClone}
+	case convertible(src, dst):
+		return rule{kind: ruleConvert}
+	case (isScalarPointer(src) || isScalarPointer(dst)) && pointerRule(src, dst).kind != ruleUnsupported:
+		return rule{kind: rulePointer}
+	case isStructLike(src) && isStructLike(dst):
+		return rule{kind: ruleNested}
+	case isSequence(src) && isSequence(dst) && supported(src.Elem(), dst.Elem(), seen):
+		return rule{kind: ruleSequence}
+	case src.Kind() == reflect.Map && dst.Kind() == reflect.Map &&
+		(isIntegerKeyPair(src.Key(), dst.Key()) || supported(src.Key(), dst.Key(), seen)) &&
+		supported(src.Elem(), dst.Elem(), seen):
+		return rule{kind: ruleMap}
+	}
+	return rule{kind: ruleUnsupported}
+}
+
+// supported reports whether the elements src and dst of a sequence or map
+// pair have a rule. A pair already being resolved is a recursive type, such as
+// type T []T, and is supported as far as its own elements are.
+func supported(src, dst reflect.Type, seen map[typePair]bool) bool {
+	pair := typePair{src: src, dst: dst}
+	if seen[pair] {
+		return true
+	}
+	seen[pair] = true
+	defer delete(seen, pair)
+	return resolveKind(src, dst, seen).kind != ruleUnsupported
+}
+
+// isShared reports whether assigning src to dst would share a slice or a map
+func isShared(src, dst reflect.Type) bool {
+	return (src.Kind() == reflect.Slice || src.Kind() == reflect.Map) && dst.Kind() == src.Kind()
+}
+
+// isFlat reports whether the values of t hold no pointer, slice, map or other
+// reference, so that copying their memory copies them
+func isFlat(t reflect.Type) bool {
+	switch {
+	case isScalar(t):
+		return true
+	case t.Kind() == reflect.Array:
+		return isFlat(t.Elem())
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isFlat(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// ruleKey identifies a cached rule, gen is the registry generation it was
+// resolved in
+type ruleKey struct {
+	typePair
+	gen uint64
+}
+
+// planKey identifies a cached struct mapping, match is the fingerprint of the
+// match strategies it was resolved with and gen the registry generation
+type planKey struct {
+	typePair
+	match string
+	gen   uint64
+}
+
+var (
+	// rules caches resolveRule by ruleKey
+	rules sync.Map
+	// plans caches mapStruct by planKey
+	plans sync.Map
+	// generation counts the registry changes, a copy that resolved a rule
+	// before a change stores it under the old generation, where it is not
+	// looked up again
+	generation uint64
+)
+
+// ruleFor returns the cached rule for a type pair
+func ruleFor(src, dst reflect.Type) rule {
+	key := ruleKey{typePair: typePair{src: src, dst: dst}, gen: atomic.LoadUint64(&generation)}
+	if r, ok := rules.Load(key); ok {
+		return r.(rule)
+	}
+	r := resolveRule(src, dst)
+	rules.Store(key, r)
+	return r
+}
+
+// planFor returns the cached field mapping from srcType to dstType
+func planFor(dstType, srcType reflect.Type, o *options) *structMapping {
+	key := planKey{typePair: typePair{src: srcType, dst: dstType}, match: o.matchKey, gen: atomic.LoadUint64(&generation)}
+	if m, ok := plans.Load(key); ok {
+		return m.(*structMapping)
+	}
+	m, _ := plans.LoadOrStore(key, mapStruct(dstType, srcType, o.match))
+	return m.(*structMapping)
+}
+
+// resetCaches starts a new generation and drops every cached rule and plan,
+// they are resolved again on next use. The registries call it once they have
+// changed.
+func resetCaches() {
+	atomic.AddUint64(&generation, 1)
+	rules.Range(func(key, _ interface{}) bool {
+		rules.Delete(key)
+		return true
+	})
+	plans.Range(func(key, _ interface{}) bool {
+		plans.Delete(key)
+		return true
+	})
+}
+
+// Mapper copies between one compiled pair of struct types
+type Mapper struct {
+	dst  reflect.Type
+	src  reflect.Type
+	opts *options
+	// plan holds the compiledPlan of the last copy
+	plan atomic.Value
+}
+
+// compiledPlan is the mapping of a Mapper and the registry generation it was
+// resolved in
+type compiledPlan struct {
+	gen     uint64
+	mapping *structMapping
+}
+
+// Compile checks the pair from srcType to dstType and returns a Mapper to
+// reuse for every copy of that pair. Both types are structs or pointers to
+// structs, opts apply to every copy made by the Mapper.
+// The Mapper resolves its mapping on the first copy, so a package level Mapper
+// of generated messages doesn't read their descriptors before the init of
+// their package has registered them, and keeps it for the next copies. It is
+// resolved again after RegisterConverter, RegisterEnum or RegisterOneofCase.
+func Compile(dstType, srcType reflect.Type, opts ...Option) (*Mapper, error) {
+	dst, src := structType(dstType), structType(srcType)
+	if dst == nil {
+		return nil, errors.Errorf("dest type %v should be a struct or a struct pointer", dstType)
+	}
+	if src == nil {
+		return nil, errors.Errorf("src type %v should be a struct or a struct pointer", srcType)
+	}
+	return &Mapper{dst: dst, src: src, opts: newOptions(opts)}, nil
+}
+
+// mapping returns the mapping of m for the current registry generation
+func (m *Mapper) mapping() *structMapping {
+	gen := atomic.LoadUint64(&generation)
+	if p, ok := m.plan.Load().(compiledPlan); ok && p.gen == gen {
+		return p.mapping
+	}
+	mapping := planFor(m.dst, m.src, m.opts)
+	m.plan.Store(compiledPlan{gen: gen, mapping: mapping})
+	return mapping
+}
+
+// MustCompile is like Compile but panics when the types are not structs,
+// it is meant for package level Mappers
+func MustCompile(dstType, srcType reflect.Type, opts ...Option) *Mapper {
+	m, err := Compile(dstType, srcType, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Copy copies src, a value of or pointer to the compiled source type, into
+// dst, a pointer to the compiled destination type
+func (m *Mapper) Copy(dst, src interface{}) error {
+	if t := reflect.TypeOf(dst); t == nil || t.Kind() != reflect.Ptr || t.Elem() != m.dst {
+		return errors.Errorf("dest type should be *%v", m.dst)
+	}
+	if structType(reflect.TypeOf(src)) != m.src {
+		return errors.Errorf("src type should be %v or *%v", m.src, m.src)
+	}
+	return run(&state{opts: m.opts}, dst, src, func(s *state, dst, src interface{}) error {
+		return copyRoot(s, dst, src, m.mapping())
+	})
+}
+
+// structType returns t or its element when t is a struct or a struct pointer, nil otherwise
+func structType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
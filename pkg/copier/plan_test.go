@@ -0,0 +1,127 @@
+package copier
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/alexwangfufa/struct-copy/example/api/material-group/v1"
+	"github.com/alexwangfufa/struct-copy/example/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type cachedEntity struct {
+	Label label
+}
+
+type cachedModel struct {
+	Label string
+}
+
+type label struct {
+	Text string
+}
+
+type stamp struct {
+	Unix int64
+}
+
+type stampEntity struct {
+	At stamp
+}
+
+type stampModel struct {
+	At int64
+}
+
+func Test_Compile(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+
+	mapper, err := Compile(reflect.TypeOf(&v1.MaterialGroupModel{}), reflect.TypeOf(domain.MaterialGroup{}))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	model := &v1.MaterialGroupModel{}
+	if err := mapper.Copy(model, &domain.MaterialGroup{Id: &objectID, Name: "compiled"}); err != nil {
+		t.Fatalf("mapper copy: %v", err)
+	}
+	if model.Id != objectID.Hex() || model.Name != "compiled" {
+		t.Fatalf("unexpected model: %v", model)
+	}
+	if err := mapper.Copy(&domain.MaterialGroup{}, &domain.MaterialGroup{}); err == nil {
+		t.Fatalf("expected error for the wrong destination type")
+	}
+	if err := mapper.Copy(model, &v1.MaterialGroupModel{}); err == nil {
+		t.Fatalf("expected error for the wrong source type")
+	}
+	if _, err := Compile(reflect.TypeOf(""), reflect.TypeOf(domain.MaterialGroup{})); err == nil {
+		t.Fatalf("expected error compiling a non struct type")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected MustCompile to panic for a non struct type")
+			}
+		}()
+		MustCompile(reflect.TypeOf(""), reflect.TypeOf(domain.MaterialGroup{}))
+	}()
+
+	// a converter registered after the plan was cached is still picked up
+	if err := Copy(&cachedModel{}, &cachedEntity{}, RequireSupported()); err == nil {
+		t.Fatalf("expected unsupported label before registering a converter")
+	}
+	RegisterConverter(reflect.TypeOf(label{}), reflect.TypeOf(""), func(src interface{}) (interface{}, error) {
+		return src.(label).Text, nil
+	})
+	cached := &cachedModel{}
+	if err := Copy(cached, &cachedEntity{Label: label{Text: "late"}}, RequireSupported()); err != nil || cached.Label != "late" {
+		t.Fatalf("converter registered late was not used: %v %v", cached, err)
+	}
+
+	// a Mapper keeps its mapping until the registry changes
+	stamps := MustCompile(reflect.TypeOf(stampModel{}), reflect.TypeOf(stampEntity{}), RequireSupported())
+	if err := stamps.Copy(&stampModel{}, &stampEntity{}); err == nil {
+		t.Fatalf("expected unsupported stamp before registering a converter")
+	}
+	compiled := stamps.mapping()
+	if err := stamps.Copy(&stampModel{}, &stampEntity{}); err == nil || stamps.mapping() != compiled {
+		t.Fatalf("Mapper resolved its mapping again: %v", err)
+	}
+	RegisterConverter(reflect.TypeOf(stamp{}), reflect.TypeOf(int64(0)), func(src interface{}) (interface{}, error) {
+		return src.(stamp).Unix, nil
+	})
+	stamped := &stampModel{}
+	if err := stamps.Copy(stamped, &stampEntity{At: stamp{Unix: 7}}); err != nil || stamped.At != 7 || stamps.mapping() == compiled {
+		t.Fatalf("Mapper kept its mapping after RegisterConverter: %v %v", stamped, err)
+	}
+
+	// a rule resolved by a copy that was running during the registration is
+	// stored under the previous generation and not used afterwards
+	pair := typePair{src: reflect.TypeOf(label{}), dst: reflect.TypeOf(0)}
+	gen := atomic.LoadUint64(&generation)
+	RegisterConverter(pair.src, pair.dst, func(src interface{}) (interface{}, error) {
+		return len(src.(label).Text), nil
+	})
+	rules.Store(ruleKey{typePair: pair, gen: gen}, rule{kind: ruleUnsupported})
+	if r := ruleFor(pair.src, pair.dst); r.kind != ruleConverter {
+		t.Fatalf("stale rule %v used after RegisterConverter", r.kind)
+	}
+}
+
+func Benchmark_Copy(b *testing.B) {
+	objectID, _ := primitive.ObjectIDFromHex("5dbba1e31fd96208db5a00a1")
+	src := &domain.MaterialGroup{Id: &objectID, Name: "bench", Order: 1, UserId: "u", UpdateTime: time.Now()}
+	mapper, _ := Compile(reflect.TypeOf(v1.SaveMaterialGroupRequest{}), reflect.TypeOf(src))
+
+	b.Run("Copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = Copy(&v1.SaveMaterialGroupRequest{}, src)
+		}
+	})
+	b.Run("Mapper", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = mapper.Copy(&v1.SaveMaterialGroupRequest{}, src)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package copier
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoEnum is the interface of every generated proto enum type
+var protoEnum = reflect.TypeOf((*protoreflect.Enum)(nil)).Elem()
+
+// protoEnumConverter converts a proto enum to and from a Go string type by
+// value name and a Go integer type by number, nil for other type pairs
+func protoEnumConverter(src, dst reflect.Type) *converter {
+	switch {
+	case isProtoEnum(src) && !isProtoEnum(dst):
+		switch {
+		case dst.Kind() == reflect.String:
+			return newConverter(src, dst, func(s *state, dst, src reflect.Value) error {
+				v, err := enumValue(src)
+				if err != nil {
+					return err
+				}
+				dst.SetString(enumName(s.opts, v))
+				return nil
+			})
+		case isInteger(dst.Kind()):
+			return newConverter(src, dst, func(_ *state, dst, src reflect.Value) error {
+				v, err := enumValue(src)
+				if err != nil {
+					return err
+				}
+				n := int64(v.Number())
+				if isUnsigned(dst.Kind()) {
+					if n < 0 || dst.OverflowUint(uint64(n)) {
+						return errors.Wrapf(ErrUnknownEnum, "%v does not fit %v", v.Name(), dst.Type())
+					}
+					dst.SetUint(uint64(n))
+					return nil
+				}
+				if dst.OverflowInt(n) {
+					return errors.Wrapf(ErrUnknownEnum, "%v does not fit %v", v.Name(), dst.Type())
+				}
+				dst.SetInt(n)
+				return nil
+			})
+		}
+	case isProtoEnum(dst) && !isProtoEnum(src):
+		desc := reflect.Zero(dst).Interface().(protoreflect.Enum).Descriptor()
+		switch {
+		case src.Kind() == reflect.String:
+			return newConverter(src, dst, func(s *state, dst, src reflect.Value) error {
+				values := desc.Values()
+				for i := 0; i < values.Len(); i++ {
+					if v := values.Get(i); enumName(s.opts, v) == src.String() {
+						dst.SetInt(int64(v.Number()))
+						return nil
+					}
+				}
+				return errors.Wrapf(ErrUnknownEnum, "%q is not a %v", src.String(), desc.FullName())
+			})
+		case isInteger(src.Kind()):
+			return newConverter(src, dst, func(_ *state, dst, src reflect.Value) error {
+				n, ok := enumNumber(src)
+				if !ok || desc.Values().ByNumber(n) == nil {
+					return errors.Wrapf(ErrUnknownEnum, "%v is not a %v", src, desc.FullName())
+				}
+				dst.SetInt(int64(n))
+				return nil
+			})
+		}
+	}
+	return nil
+}
+
+// isProtoEnum reports whether t is a generated proto enum type, pointers to one excluded
+func isProtoEnum(t reflect.Type) bool {
+	return t.Kind() == reflect.Int32 && t.Implements(protoEnum)
+}
+
+// enumValue returns the descriptor of the value held by a proto enum, numbers
+// the enum doesn't declare are unknown
+func enumValue(src reflect.Value) (protoreflect.EnumValueDescriptor, error) {
+	e := src.Interface().(protoreflect.Enum)
+	v := e.Descriptor().Values().ByNumber(e.Number())
+	if v == nil {
+		return nil, errors.Wrapf(ErrUnknownEnum, "%d is not a %v", e.Number(), e.Descriptor().FullName())
+	}
+	return v, nil
+}
+
+// enumName is the Go string of a proto enum value: its entry in the EnumNames
+// table, or else the empty string for the zero value, or else its name without
+// the prefix and in lower case
+func enumName(o *options, v protoreflect.EnumValueDescriptor) string {
+	name := string(v.Name())
+	if s, ok := o.enumNames[name]; ok {
+		return s
+	}
+	if v.Number() == 0 {
+		return ""
+	}
+	prefixes := o.enumPrefixes
+	if prefixes == nil {
+		prefixes = []string{enumPrefix(v.Parent().Name())}
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// enumNumber reads an integer as an enum number, ok is false when it doesn't fit an int32
+func enumNumber(v reflect.Value) (n protoreflect.EnumNumber, ok bool) {
+	if isUnsigned(v.Kind()) {
+		return protoreflect.EnumNumber(v.Uint()), v.Uint() <= math.MaxInt32
+	}
+	return protoreflect.EnumNumber(v.Int()), v.Int() == int64(protoreflect.EnumNumber(v.Int()))
+}
+
+// enumPrefix is the value prefix the proto style guide asks for,
+// MATERIAL_GROUP_SCOPE_ for the enum MaterialGroupScope
+func enumPrefix(enum protoreflect.Name) string {
+	runes := []rune(string(enum))
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	b.WriteByte('_')
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package copier
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type fieldType string
+
+type fieldLabel int
+
+type fieldEntity struct {
+	Type   fieldType
+	Label  fieldLabel
+	JSType string
+	CType  string
+}
+
+type fieldProto struct {
+	Type   descriptorpb.FieldDescriptorProto_Type
+	Label  descriptorpb.FieldDescriptorProto_Label
+	JSType descriptorpb.FieldOptions_JSType
+	CType  descriptorpb.FieldOptions_CType
+}
+
+func Test_CopyProtoEnums(t *testing.T) {
+	src := &fieldProto{
+		Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_REPEATED,
+		JSType: descriptorpb.FieldOptions_JS_NUMBER,
+		CType:  descriptorpb.FieldOptions_STRING,
+	}
+
+	// TYPE_ is the prefix of Type, JSType values don't start with JS_TYPE_ and
+	// the zero value STRING becomes ""
+	entity := &fieldEntity{}
+	if err := Copy(entity, src); err != nil {
+		t.Fatalf("copy proto: %v", err)
+	}
+	if *entity != (fieldEntity{Type: "string", Label: 3, JSType: "js_number", CType: ""}) {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+	back := &fieldProto{}
+	if err := Copy(back, entity); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if *back != *src {
+		t.Fatalf("unexpected proto: %+v", back)
+	}
+
+	names := []Option{EnumPrefix("JS_"), EnumNames(map[string]string{"TYPE_STRING": "text", "STRING": "plain"})}
+	entity = &fieldEntity{}
+	if err := Copy(entity, src, names...); err != nil {
+		t.Fatalf("copy proto with names: %v", err)
+	}
+	if *entity != (fieldEntity{Type: "text", Label: 3, JSType: "number", CType: "plain"}) {
+		t.Fatalf("unexpected entity with names: %+v", entity)
+	}
+	back = &fieldProto{}
+	if err := Copy(back, entity, names...); err != nil {
+		t.Fatalf("copy entity with names: %v", err)
+	}
+	if *back != *src {
+		t.Fatalf("unexpected proto with names: %+v", back)
+	}
+
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+		path string
+	}{
+		{"unknown name", &fieldProto{}, &fieldEntity{Type: "text"}, "Type"},
+		{"no zero value", &fieldProto{}, &fieldEntity{Type: "", Label: 1}, "Type"},
+		{"unknown number", &fieldProto{}, &fieldEntity{Type: "bool", Label: 9}, "Label"},
+		{"number over int32", &fieldProto{}, &struct{ Label int64 }{Label: 1<<32 + 1}, "Label"},
+		{"undeclared proto value", &fieldEntity{}, &fieldProto{Type: 99}, "Type"},
+		{"undeclared proto number", &fieldEntity{}, &fieldProto{Type: 1, Label: 9}, "Label"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != tt.path || !errors.Is(err, ErrUnknownEnum) {
+				t.Fatalf("expected ErrUnknownEnum at %s, got %v", tt.path, err)
+			}
+		})
+	}
+
+	if got := enumPrefix("MaterialGroupScope"); got != "MATERIAL_GROUP_SCOPE_" {
+		t.Fatalf("enumPrefix(MaterialGroupScope) = %s", got)
+	}
+	if got := enumPrefix("JSType"); got != "JS_TYPE_" {
+		t.Fatalf("enumPrefix(JSType) = %s", got)
+	}
+}
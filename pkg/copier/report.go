@@ -0,0 +1,91 @@
+package copier
+
+import "reflect"
+
+// Action is what a copy did with one destination field
+type Action string
+
+const (
+	// Copied means the source value was assigned as it is
+	Copied Action = "copied"
+	// Converted means the value went through a converter or a Go conversion
+	Converted Action = "converted"
+	// Skipped means the destination field was left untouched
+	Skipped Action = "skipped"
+	// Failed means copying the field returned an error
+	Failed Action = "failed"
+)
+
+// reasons a destination field is skipped
+const (
+	ReasonNoSource    = "no source field"
+	ReasonNilPointer  = "nil source pointer"
+	ReasonNilSlice    = "nil source slice"
+	ReasonNilMap      = "nil source map"
+	ReasonUnsupported = "unsupported type pair"
+	ReasonIgnored     = "ignored by copier tag"
+	ReasonOneofUnset  = "oneof case not set"
+)
+
+// converter names reported for plain Go conversions, nested struct copies,
+// element by element copies and oneof copies
+const (
+	goConversion = "go conversion"
+	nestedCopy   = "nested copy"
+	elementCopy  = "element copy"
+	oneofCopy    = "oneof copy"
+)
+
+// FieldReport describes what happened to one destination field
+type FieldReport struct {
+	Path    string
+	SrcType reflect.Type
+	DstType reflect.Type
+	Action  Action
+	// Reason tells why a field was skipped
+	Reason string
+	// Converter names the conversion used for a converted field
+	Converter string
+	// Err is set for failed fields
+	Err error
+}
+
+// Report lists one entry per destination field, in copy order
+type Report struct {
+	DryRun bool
+	Fields []FieldReport
+}
+
+// Field returns the entry for the destination field at path
+func (r *Report) Field(path string) (FieldReport, bool) {
+	for _, f := range r.Fields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return FieldReport{}, false
+}
+
+// CopyWithReport copies like Copy and describes what happened to every
+// destination field. With the DryRun option dst is left untouched.
+func CopyWithReport(dst, src interface{}, opts ...Option) (*Report, error) {
+	s := newState(opts)
+	s.report = &Report{DryRun: s.opts.dryRun}
+	err := run(s, dst, src, copy)
+	return s.report, err
+}
+
+// record adds an entry to the report, if one is being built
+func (s *state) record(path string, dst, src reflect.Value, f FieldReport) {
+	if s.report == nil {
+		return
+	}
+	f.Path = path
+	if dst.IsValid() {
+		f.DstType = dst.Type()
+	}
+	if src.IsValid() {
+		f.SrcType = src.Type()
+	}
+	s.report.Fields = append(s.report.Fields, f)
+}
@@ -0,0 +1,43 @@
+package copier
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// CopySlice copies every element of the slice or array src into the slice
+// pointed to by dstSlicePtr, e.g. a []domain.MaterialGroup into a
+// *[]*v1.MaterialGroupModel. The destination is allocated once, the struct
+// mappings are resolved once for all elements, and failures carry the element
+// index in their path, e.g. [3].Id.
+func CopySlice(dstSlicePtr, srcSlice interface{}, opts ...Option) error {
+	return run(newState(opts), dstSlicePtr, srcSlice, copySlice)
+}
+
+func copySlice(s *state, dst, src interface{}) error {
+	dstType, dstValue := reflect.TypeOf(dst), reflect.ValueOf(dst)
+	srcValue := reflect.ValueOf(src)
+
+	if dstType == nil || dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Slice || dstValue.IsNil() {
+		return errors.New("dest type should be a slice pointer")
+	}
+
+	if srcValue.Kind() == reflect.Ptr && !srcValue.IsNil() {
+		srcValue = srcValue.Elem()
+	}
+
+	if !srcValue.IsValid() || !isSequence(srcValue.Type()) {
+		return errors.New("src type should be a slice or an array")
+	}
+
+	dstValue = dstValue.Elem()
+	if s.opts.dryRun {
+		dstValue = reflect.New(dstValue.Type()).Elem()
+	}
+
+	if err := copySequence(s, dstValue, srcValue, ""); err != nil {
+		return newCopyError("", dstValue, srcValue, err)
+	}
+	return nil
+}
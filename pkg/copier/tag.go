@@ -0,0 +1,118 @@
+package copier
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagName is the struct tag key read by the copier
+const tagName = "copier"
+
+// fieldTag is the parsed form of a `copier:"..."` struct tag:
+//
+//	copier:"-"                  never copy the field, in either direction
+//	copier:"OrganizationId"     the counterpart field is OrganizationId, in both directions
+//	copier:"from=UserId|OwnerId" when written, take the first of UserId or OwnerId that exists
+//	copier:"to=-"               when read, never copy the field out
+//	copier:"zero=nil"           a zero value becomes a nil wrapper, timestamp or scalar pointer,
+//	                            zero=keep wraps it, overriding ZeroWrappers, ZeroTimes and
+//	                            ZeroPointers for the field
+//
+// Items are separated by commas, e.g. copier:"OrganizationId,to=-".
+// A from or to list of "-" turns that direction off.
+type fieldTag struct {
+	ignore bool
+	name   string
+	from   []string
+	to     []string
+	noFrom bool
+	noTo   bool
+	zero   zeroTag
+}
+
+// zeroTag is the zero= item of a copier tag
+type zeroTag int
+
+const (
+	zeroUnset zeroTag = iota
+	zeroNil
+	zeroKeep
+)
+
+func parseTag(field reflect.StructField) fieldTag {
+	var t fieldTag
+	tag, ok := field.Tag.Lookup(tagName)
+	if !ok {
+		return t
+	}
+	if tag == "-" {
+		t.ignore = true
+		return t
+	}
+
+	for i, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		key, value, hasValue := strings.Cut(item, "=")
+		switch {
+		case hasValue && key == "from":
+			t.from, t.noFrom = splitNames(value)
+		case hasValue && key == "to":
+			t.to, t.noTo = splitNames(value)
+		case hasValue && key == "zero" && value == "nil":
+			t.zero = zeroNil
+		case hasValue && key == "zero" && value == "keep":
+			t.zero = zeroKeep
+		case i == 0 && !hasValue:
+			t.name = item
+		}
+	}
+	return t
+}
+
+// splitNames splits a "A|B" list, "-" means the direction is turned off
+func splitNames(value string) ([]string, bool) {
+	if value == "-" {
+		return nil, true
+	}
+	var names []string
+	for _, name := range strings.Split(value, "|") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, false
+}
+
+// sources returns the source field names a destination field asks for, nil when
+// the tag doesn't name any
+func (t fieldTag) sources() []string {
+	if len(t.from) > 0 {
+		return t.from
+	}
+	if t.name != "" {
+		return []string{t.name}
+	}
+	return nil
+}
+
+// targets returns the destination field names a source field asks for, nil when
+// the tag doesn't name any
+func (t fieldTag) targets() []string {
+	if len(t.to) > 0 {
+		return t.to
+	}
+	if t.name != "" {
+		return []string{t.name}
+	}
+	return nil
+}
+
+// writable reports whether the field may be used as a destination
+func (t fieldTag) writable() bool {
+	return !t.ignore && !t.noFrom
+}
+
+// readable reports whether the field may be used as a source
+func (t fieldTag) readable() bool {
+	return !t.ignore && !t.noTo
+}
@@ -0,0 +1,199 @@
+package copier
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrTimeRange is the cause reported for timestamps and durations outside of
+// what the destination can hold, see timestamppb.Timestamp.CheckValid
+var ErrTimeRange = errors.New("time out of range")
+
+// timeReader reads a time from a non nil source value, ok is false when the
+// source holds no time and dst is left untouched
+type timeReader func(s *state, src reflect.Value) (t time.Time, ok bool, err error)
+
+// timeWriter stores t into dst
+type timeWriter func(s *state, dst reflect.Value, t time.Time) error
+
+// timeReaders and timeWriters hold every type that stands for a point in time,
+// strings and int64 only when TimeLayout and UnixTime ask for them, ObjectIDs
+// only with ObjectIDTime. Each reader is registered against each writer, see init.
+var (
+	timeReaders = map[reflect.Type]timeReader{
+		timestamp: func(_ *state, src reflect.Value) (time.Time, bool, error) {
+			return src.Interface().(time.Time), true, nil
+		},
+		timestampPtr: func(_ *state, src reflect.Value) (time.Time, bool, error) {
+			return *src.Interface().(*time.Time), true, nil
+		},
+		pbTimestamp: func(_ *state, src reflect.Value) (time.Time, bool, error) {
+			ts := src.Interface().(*timestamppb.Timestamp)
+			if err := ts.CheckValid(); err != nil {
+				return time.Time{}, false, errors.Wrap(ErrTimeRange, err.Error())
+			}
+			return ts.AsTime(), true, nil
+		},
+		dateTime: func(_ *state, src reflect.Value) (time.Time, bool, error) {
+			return src.Interface().(primitive.DateTime).Time().UTC(), true, nil
+		},
+		objectID:    objectIDTime,
+		objectIDPtr: objectIDTime,
+		stringType: func(s *state, src reflect.Value) (time.Time, bool, error) {
+			if s.opts.timeLayout == "" {
+				return time.Time{}, false, ErrUnsupported
+			}
+			if src.String() == "" {
+				return time.Time{}, false, nil
+			}
+			loc := s.opts.timeZone
+			if loc == nil {
+				loc = time.UTC
+			}
+			t, err := time.ParseInLocation(s.opts.timeLayout, src.String(), loc)
+			return t, err == nil, err
+		},
+		int64Type: func(s *state, src reflect.Value) (time.Time, bool, error) {
+			if s.opts.unixTime == 0 {
+				return time.Time{}, false, ErrUnsupported
+			}
+			if src.Int() == 0 && s.zeroTimeToNil() {
+				return time.Time{}, false, nil
+			}
+			return fromUnix(src.Int(), s.opts.unixTime), true, nil
+		},
+	}
+
+	timeWriters = map[reflect.Type]timeWriter{
+		timestamp: func(_ *state, dst reflect.Value, t time.Time) error {
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		},
+		timestampPtr: func(s *state, dst reflect.Value, t time.Time) error {
+			if t.IsZero() && s.zeroTimeToNil() {
+				dst.Set(reflect.Zero(dst.Type()))
+				return nil
+			}
+			dst.Set(reflect.ValueOf(&t))
+			return nil
+		},
+		pbTimestamp: func(s *state, dst reflect.Value, t time.Time) error {
+			if t.IsZero() && s.zeroTimeToNil() {
+				dst.Set(reflect.Zero(dst.Type()))
+				return nil
+			}
+			ts := timestamppb.New(t)
+			if err := ts.CheckValid(); err != nil {
+				return errors.Wrap(ErrTimeRange, err.Error())
+			}
+			dst.Set(reflect.ValueOf(ts))
+			return nil
+		},
+		dateTime: func(_ *state, dst reflect.Value, t time.Time) error {
+			dst.Set(reflect.ValueOf(primitive.NewDateTimeFromTime(t)))
+			return nil
+		},
+		stringType: func(s *state, dst reflect.Value, t time.Time) error {
+			if s.opts.timeLayout == "" {
+				return ErrUnsupported
+			}
+			if t.IsZero() && s.zeroTimeToNil() {
+				dst.SetString("")
+				return nil
+			}
+			dst.SetString(t.Format(s.opts.timeLayout))
+			return nil
+		},
+		int64Type: func(s *state, dst reflect.Value, t time.Time) error {
+			if s.opts.unixTime == 0 {
+				return ErrUnsupported
+			}
+			if t.IsZero() && s.zeroTimeToNil() {
+				dst.SetInt(0)
+				return nil
+			}
+			dst.SetInt(toUnix(t, s.opts.unixTime))
+			return nil
+		},
+	}
+
+	// encodedTimes are the types that only hold a time under an option,
+	// they don't convert into each other
+	encodedTimes = map[reflect.Type]bool{stringType: true, int64Type: true, objectID: true, objectIDPtr: true}
+)
+
+func init() {
+	// every pair of time types converts, the same type included so that the
+	// precision and time zone options apply to a plain time.Time field too
+	for srcType, read := range timeReaders {
+		for dstType, write := range timeWriters {
+			if encodedTimes[srcType] && encodedTimes[dstType] {
+				continue
+			}
+			read, write := read, write
+			register(srcType, dstType, func(s *state, dst, src reflect.Value) error {
+				t, ok, err := read(s, src)
+				if err != nil || !ok {
+					return err
+				}
+				return write(s, dst, normalizeTime(s.opts, t))
+			})
+		}
+	}
+}
+
+// objectIDTime reads the creation time embedded in an ObjectID, the zero id holds no time
+func objectIDTime(s *state, src reflect.Value) (time.Time, bool, error) {
+	if !s.opts.objectIDTime {
+		return time.Time{}, false, ErrUnsupported
+	}
+	id := objectIDOf(src)
+	if id.IsZero() {
+		return time.Time{}, false, nil
+	}
+	return id.Timestamp().UTC(), true, nil
+}
+
+// normalizeTime applies TimePrecision and TimeZone, the zero time stays zero
+func normalizeTime(o *options, t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	if o.timePrecision > 0 {
+		t = t.Truncate(o.timePrecision)
+	}
+	if o.timeZone != nil {
+		t = t.In(o.timeZone)
+	}
+	return t
+}
+
+// fromUnix reads n as a count of unit since the unix epoch
+func fromUnix(n int64, unit time.Duration) time.Time {
+	switch unit {
+	case time.Second:
+		return time.Unix(n, 0)
+	case time.Millisecond:
+		return time.UnixMilli(n)
+	case time.Microsecond:
+		return time.UnixMicro(n)
+	}
+	return time.Unix(0, n*int64(unit))
+}
+
+// toUnix counts t in unit since the unix epoch
+func toUnix(t time.Time, unit time.Duration) int64 {
+	switch unit {
+	case time.Second:
+		return t.Unix()
+	case time.Millisecond:
+		return t.UnixMilli()
+	case time.Microsecond:
+		return t.UnixMicro()
+	}
+	return t.UnixNano() / int64(unit)
+}
@@ -0,0 +1,152 @@
+package copier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type timeEntity struct {
+	Created time.Time
+	Updated *time.Time
+	Stored  primitive.DateTime
+	Deleted time.Time
+}
+
+type timeModel struct {
+	Created *timestamppb.Timestamp
+	Updated *timestamppb.Timestamp
+	Stored  *timestamppb.Timestamp
+	Deleted *time.Time
+}
+
+func Test_CopyTime(t *testing.T) {
+	// nanoseconds are kept, only primitive.DateTime rounds to milliseconds
+	now := time.Date(2022, 3, 4, 5, 6, 7, 123456789, time.UTC)
+	stored := primitive.NewDateTimeFromTime(now)
+
+	model := &timeModel{}
+	if err := Copy(model, &timeEntity{Created: now, Updated: &now, Stored: stored}); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	if !model.Created.AsTime().Equal(now) || !model.Updated.AsTime().Equal(now) {
+		t.Fatalf("unexpected times: %v %v", model.Created.AsTime(), model.Updated.AsTime())
+	}
+	if !model.Stored.AsTime().Equal(now.Truncate(time.Millisecond)) {
+		t.Fatalf("unexpected stored time: %v", model.Stored.AsTime())
+	}
+	if model.Deleted != nil {
+		t.Fatalf("zero time should become nil, got %v", model.Deleted)
+	}
+
+	entity := &timeEntity{}
+	if err := Copy(entity, model); err != nil {
+		t.Fatalf("copy model: %v", err)
+	}
+	if !entity.Created.Equal(now) || entity.Updated == nil || !entity.Updated.Equal(now) || entity.Stored != stored {
+		t.Fatalf("unexpected entity: %+v", entity)
+	}
+
+	model = &timeModel{}
+	if err := Copy(model, &timeEntity{}, ZeroTimes(ZeroTimeKeep)); err != nil {
+		t.Fatalf("copy zero times: %v", err)
+	}
+	if model.Created == nil || !model.Created.AsTime().IsZero() || model.Deleted == nil || !model.Deleted.IsZero() {
+		t.Fatalf("zero times should be kept: %v %v", model.Created, model.Deleted)
+	}
+
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+	}{
+		{"invalid timestamp", &timeEntity{}, &timeModel{Created: &timestamppb.Timestamp{Seconds: 1, Nanos: -1}}},
+		{"timestamp before year 1", &timeEntity{}, &timeModel{Created: &timestamppb.Timestamp{Seconds: -62135596801}}},
+		{"time after year 9999", &timeModel{}, &timeEntity{Created: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src)
+			var ce *CopyError
+			if !errors.As(err, &ce) || ce.Path != "Created" || !errors.Is(err, ErrTimeRange) {
+				t.Fatalf("expected ErrTimeRange at Created, got %v", err)
+			}
+		})
+	}
+}
+
+type timePolicyEntity struct {
+	At      time.Time
+	Seen    []time.Time
+	Nested  timeEntity
+	Expires time.Time
+}
+
+type timePolicyModel struct {
+	At      string
+	Seen    []int64
+	Nested  timeModel
+	Expires int64
+}
+
+func Test_TimePolicy(t *testing.T) {
+	shanghai := time.FixedZone("Asia/Shanghai", 8*60*60)
+	now := time.Date(2022, 3, 4, 5, 6, 7, 123456789, time.UTC)
+
+	// a plain time.Time field follows the precision and time zone too
+	entity := &timeEntity{}
+	if err := Copy(entity, &timeEntity{Created: now, Updated: &now}, TimePrecision(time.Millisecond), TimeZone(shanghai)); err != nil {
+		t.Fatalf("copy entity: %v", err)
+	}
+	want := now.Truncate(time.Millisecond)
+	if !entity.Created.Equal(want) || entity.Created.Location() != shanghai || !entity.Updated.Equal(want) {
+		t.Fatalf("unexpected times: %v %v", entity.Created, entity.Updated)
+	}
+
+	model := &timePolicyModel{}
+	src := &timePolicyEntity{At: now, Seen: []time.Time{now, now.Add(time.Second)}, Nested: timeEntity{Created: now}}
+	err := Copy(model, src, TimeLayout(time.RFC3339Nano), UnixTime(time.Millisecond), TimePrecision(time.Microsecond), TimeZone(shanghai))
+	if err != nil {
+		t.Fatalf("copy policy entity: %v", err)
+	}
+	if model.At != "2022-03-04T13:06:07.123456+08:00" {
+		t.Fatalf("unexpected string time: %v", model.At)
+	}
+	if len(model.Seen) != 2 || model.Seen[0] != now.UnixMilli() || model.Seen[1] != now.UnixMilli()+1000 {
+		t.Fatalf("unexpected unix times: %v", model.Seen)
+	}
+	if !model.Nested.Created.AsTime().Equal(now.Truncate(time.Microsecond)) {
+		t.Fatalf("unexpected nested time: %v", model.Nested.Created.AsTime())
+	}
+	if model.Expires != 0 {
+		t.Fatalf("zero time should become 0, got %v", model.Expires)
+	}
+
+	back := &timePolicyEntity{}
+	if err := Copy(back, model, TimeLayout(time.RFC3339Nano), UnixTime(time.Millisecond)); err != nil {
+		t.Fatalf("copy policy model: %v", err)
+	}
+	if !back.At.Equal(now.Truncate(time.Microsecond)) || !back.Seen[1].Equal(now.Truncate(time.Millisecond).Add(time.Second)) || !back.Expires.IsZero() {
+		t.Fatalf("unexpected entity: %+v", back)
+	}
+
+	// a string without a zone is read in the time zone
+	local := &timePolicyEntity{}
+	if err := Copy(local, &timePolicyModel{At: "2022-03-04 13:06:07"}, TimeLayout("2006-01-02 15:04:05"), TimeZone(shanghai)); err != nil {
+		t.Fatalf("copy local time: %v", err)
+	}
+	if !local.At.Equal(time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)) {
+		t.Fatalf("unexpected local time: %v", local.At)
+	}
+
+	// without the options strings and integers are not times
+	if err := Copy(&timePolicyModel{}, src, RequireSupported()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported without a layout, got %v", err)
+	}
+	if err := Copy(&timePolicyEntity{}, &timePolicyModel{At: "yesterday"}, TimeLayout(time.RFC3339)); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+}
@@ -0,0 +1,96 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: structcopy/options.proto
+
+package structcopy
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_structcopy_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         50901,
+		Name:          "structcopy.domain",
+		Tag:           "bytes,50901,opt,name=domain",
+		Filename:      "structcopy/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// domain names the Go domain type of the message as import/path.Type,
+	// protoc-gen-structcopy generates ToDomain and FromDomain for it
+	//
+	// optional string domain = 50901;
+	E_Domain = &file_structcopy_options_proto_extTypes[0]
+)
+
+var File_structcopy_options_proto protoreflect.FileDescriptor
+
+var file_structcopy_options_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x63, 0x6f, 0x70, 0x79, 0x2f, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x73, 0x74, 0x72, 0x75,
+	0x63, 0x74, 0x63, 0x6f, 0x70, 0x79, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3a, 0x39, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61,
+	0x69, 0x6e, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0xd5, 0x8d, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x42, 0x41, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x61, 0x6c, 0x65, 0x78, 0x77, 0x61, 0x6e, 0x67, 0x66, 0x75, 0x66, 0x61, 0x2f, 0x73,
+	0x74, 0x72, 0x75, 0x63, 0x74, 0x2d, 0x63, 0x6f, 0x70, 0x79, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x63, 0x6f, 0x70, 0x79, 0x3b, 0x73, 0x74, 0x72, 0x75,
+	0x63, 0x74, 0x63, 0x6f, 0x70, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var file_structcopy_options_proto_goTypes = []interface{}{
+	(*descriptorpb.MessageOptions)(nil), // 0: google.protobuf.MessageOptions
+}
+var file_structcopy_options_proto_depIdxs = []int32{
+	0, // 0: structcopy.domain:extendee -> google.protobuf.MessageOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_structcopy_options_proto_init() }
+func file_structcopy_options_proto_init() {
+	if File_structcopy_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_structcopy_options_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_structcopy_options_proto_goTypes,
+		DependencyIndexes: file_structcopy_options_proto_depIdxs,
+		ExtensionInfos:    file_structcopy_options_proto_extTypes,
+	}.Build()
+	File_structcopy_options_proto = out.File
+	file_structcopy_options_proto_rawDesc = nil
+	file_structcopy_options_proto_goTypes = nil
+	file_structcopy_options_proto_depIdxs = nil
+}